@@ -1,27 +1,64 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/joaomarcosfurtado/gnode/internal/manager"
+	"github.com/joaomarcosfurtado/gnode/internal/version"
 	"github.com/joaomarcosfurtado/gnode/pkg/config"
 )
 
 func printUsage() {
 	fmt.Println("Usage: gnode <command> [args]")
 	fmt.Println("\nCommands:")
-	fmt.Println(" install <version>     Install some Node.js version")
-	fmt.Println(" use <version>         Use some installed version")
+	fmt.Println(" install [version]     Install some Node.js version (accepts ranges like 20, ^18.17)")
+	fmt.Println(" use [version]         Use some installed version")
 	fmt.Println(" list                  List installed versions")
-	fmt.Println(" list-remote           List versions available to download")
+	fmt.Println(" list-remote [spec]    List versions available to download (e.g. lts/*, 20)")
+	fmt.Println("                       --lts[=<codename>] --major N --since/--until YYYY-MM-DD")
+	fmt.Println("                       --security-only --installable --all --channel <name>")
 	fmt.Println(" current               Show current version")
-	fmt.Println(" which                 Show the executable path of Node.js")
-	fmt.Println(" uninstall <version>   Uninstall some Node.js version")
+	fmt.Println(" which [version]       Show the executable path of Node.js")
+	fmt.Println(" uninstall <version>   Uninstall some Node.js version (--prune-aliases)")
+	fmt.Println(" outdated              Show installed major lines with a newer release available")
+	fmt.Println(" upgrade [version]     Install the newest release of a line and move aliases onto it")
+	fmt.Println("                       --all --reinstall-packages --remove-old")
+	fmt.Println(" eol                   Show the support state of installed versions (--update)")
+	fmt.Println(" audit                 List installed versions with a later security release (--json)")
+	fmt.Println(" alias [name] [spec]   List, show or set a version alias")
+	fmt.Println(" unalias <name>        Remove a version alias")
+	fmt.Println(" resolve <spec>        Print the version a spec resolves to (--installed for local)")
+	fmt.Println(" info <spec>           Show release metadata (npm, V8, OpenSSL...) (--json, --installed)")
+	fmt.Println(" mirror [list]         Show each channel's mirrors in the order they are tried")
+	fmt.Println(" mirror probe [chan]   Measure mirror speed and save the fastest-first order (--no-save)")
+	fmt.Println(" cache ls|verify       List cached archives or check them against their SHA-256")
+	fmt.Println(" cache clean           Remove cached archives (--older-than 30d)")
+	fmt.Println(" cache prefetch <ver>  Download a version's archive without installing it")
 	fmt.Println(" status                Show gnode status")
 	fmt.Println(" help                  Show this help")
 
+	fmt.Println("\nGlobal options:")
+	fmt.Println(" --refresh             Ignore the cached index.json and revalidate it")
+	fmt.Println(" --offline             Use only the cached index, archives and installed versions")
+	fmt.Println("                       (also enabled by GNODE_OFFLINE=1)")
+	fmt.Println(" --verify-signature    Require a valid release team signature on SHASUMS256.txt")
+	fmt.Println("                       (on by default with \"hardened\": true in config.json)")
+	fmt.Println(" --insecure            Skip TLS certificate verification (test mirrors only)")
+
+	fmt.Println("\nWithout a version, install and use read the nearest .nvmrc,")
+	fmt.Println(".node-version or .tool-versions file.")
+
+	fmt.Println("\nPre-release channels are selected with a prefix: rc/22, nightly/latest,")
+	fmt.Println("test/23, v8-canary/23.")
+
 	if runtime.GOOS == "windows" {
 		fmt.Println("\nWindows specific:")
 		fmt.Println(" - First 'gnode use' will configure PATH automatically")
@@ -29,7 +66,134 @@ func printUsage() {
 	}
 }
 
+// parseArgs splits command arguments into positionals and --flags. Flags are
+// written as --name or --name=value; names in valueFlags also take the next
+// argument as their value.
+func parseArgs(args []string, boolFlags, valueFlags []string) ([]string, map[string]string, error) {
+	known := map[string]bool{}
+	for _, name := range boolFlags {
+		known[name] = false
+	}
+	for _, name := range valueFlags {
+		known[name] = true
+	}
+
+	var positional []string
+	flags := map[string]string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "--") {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		takesValue, ok := known[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown option --%s", name)
+		}
+		if takesValue && !hasValue {
+			if i+1 >= len(args) {
+				return nil, nil, fmt.Errorf("option --%s requires a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		flags[name] = value
+	}
+	return positional, flags, nil
+}
+
+func parseListRemoteArgs(args []string) (manager.ListRemoteOptions, error) {
+	var opts manager.ListRemoteOptions
+
+	positional, flags, err := parseArgs(args,
+		[]string{"lts", "security-only", "installable", "all"},
+		[]string{"major", "since", "until", "channel"})
+	if err != nil {
+		return opts, err
+	}
+	if len(positional) > 1 {
+		return opts, fmt.Errorf("too many arguments")
+	}
+	if len(positional) == 1 {
+		opts.Spec = positional[0]
+	}
+
+	if channel, ok := flags["channel"]; ok && channel != "release" {
+		if !version.IsChannel(channel) {
+			return opts, fmt.Errorf("unknown channel %q, expected one of: release, %s", channel, strings.Join(version.Channels, ", "))
+		}
+		opts.Channel = channel
+	}
+	if codename, ok := flags["lts"]; ok {
+		opts.LTS = true
+		opts.LTSCodename = codename
+	}
+	if major, ok := flags["major"]; ok {
+		n, err := strconv.Atoi(strings.TrimPrefix(major, "v"))
+		if err != nil || n < 0 {
+			return opts, fmt.Errorf("invalid --major value %q", major)
+		}
+		opts.Major, opts.HasMajor = n, true
+	}
+	for _, name := range []string{"since", "until"} {
+		value, ok := flags[name]
+		if !ok {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return opts, fmt.Errorf("invalid --%s date %q, expected YYYY-MM-DD", name, value)
+		}
+		if name == "since" {
+			opts.Since = value
+		} else {
+			opts.Until = value
+		}
+	}
+	_, opts.SecurityOnly = flags["security-only"]
+	_, opts.Installable = flags["installable"]
+	_, opts.All = flags["all"]
+
+	return opts, nil
+}
+
+// parseAge reads a duration such as 30d, 12h or 90m.
+func parseAge(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid age %q, expected e.g. 30d or 12h", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	age, err := time.ParseDuration(value)
+	if err != nil || age <= 0 {
+		return 0, fmt.Errorf("invalid age %q, expected e.g. 30d or 12h", value)
+	}
+	return age, nil
+}
+
+// extractGlobalFlags removes options that apply to every command from args
+// so the per-command argument handling below only sees its own.
+func extractGlobalFlags(args []string) ([]string, map[string]bool) {
+	global := map[string]bool{"--refresh": false, "--offline": false, "--verify-signature": false, "--insecure": false}
+
+	var rest []string
+	for _, arg := range args {
+		if _, ok := global[arg]; ok {
+			global[arg] = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, global
+}
+
 func main() {
+	args, global := extractGlobalFlags(os.Args)
+	os.Args = args
+
 	if len(os.Args) < 2 {
 		printUsage()
 		os.Exit(1)
@@ -40,12 +204,45 @@ func main() {
 		fmt.Printf("Error loading configs: %v\n", err)
 		os.Exit(1)
 	}
+	cfg.RefreshIndex = global["--refresh"]
+	if global["--offline"] {
+		cfg.Offline = true
+	}
+	if global["--verify-signature"] {
+		cfg.VerifySignature = true
+	}
+	if global["--insecure"] {
+		cfg.Insecure = true
+	}
+	if cfg.Insecure {
+		if cfg.Hardened {
+			fmt.Println("Error: --insecure cannot be used with a hardened config")
+			os.Exit(1)
+		}
+		fmt.Fprintln(os.Stderr, "WARNING: --insecure disables TLS certificate verification. Anyone on the network")
+		fmt.Fprintln(os.Stderr, "WARNING: path can impersonate the mirror and serve tampered Node.js builds.")
+		fmt.Fprintln(os.Stderr, "WARNING: Only use it with test mirrors.")
+	}
+	if cfg.Offline && cfg.RefreshIndex {
+		fmt.Println("Error: --refresh cannot be used in offline mode")
+		os.Exit(1)
+	}
+
+	// Ctrl-C cancels network requests and rolls back an install in
+	// progress; a second one exits immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
 
-	mgr, err := manager.NewManager(cfg)
+	mgr, err := manager.NewManager(ctx, cfg)
 	if err != nil {
 		fmt.Printf("Error initializing manager: %v\n", err)
 		os.Exit(1)
 	}
+	mgr.SetProgress(newProgressRenderer())
 
 	if err := mgr.Init(); err != nil {
 		fmt.Printf("Error initializing directories: %v\n", err)
@@ -55,24 +252,30 @@ func main() {
 	command := os.Args[1]
 	switch command {
 	case "install":
-		if len(os.Args) < 3 {
-			fmt.Println("Usage: gnode install <version>")
+		if len(os.Args) > 3 {
+			fmt.Println("Usage: gnode install [version]")
 			os.Exit(1)
 		}
-		if err := mgr.Install(os.Args[2]); err != nil {
+		spec := ""
+		if len(os.Args) == 3 {
+			spec = os.Args[2]
+		}
+		if err := mgr.Install(spec); err != nil {
 			fmt.Printf("Error installing: %v\n", err)
 			os.Exit(1)
 		}
 	case "use":
-		if len(os.Args) < 3 {
-			fmt.Println("Use: gnode use <version>")
+		positional, flags, err := parseArgs(os.Args[2:], []string{"print-env"}, nil)
+		if err != nil || len(positional) > 1 {
+			fmt.Println("Use: gnode use [version] [--print-env]")
 			os.Exit(1)
 		}
-		printEnv := false
-		if len(os.Args) > 3 && os.Args[3] == "--print-env" {
-			printEnv = true
+		spec := ""
+		if len(positional) == 1 {
+			spec = positional[0]
 		}
-		if err := mgr.Use(os.Args[2], printEnv); err != nil {
+		_, printEnv := flags["print-env"]
+		if err := mgr.Use(spec, printEnv); err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
 		}
@@ -86,11 +289,13 @@ func main() {
 			os.Exit(1)
 		}
 	case "list-remote":
-		if len(os.Args) > 2 {
-			fmt.Println("Command 'list-remote' does not accept arguments")
+		opts, err := parseListRemoteArgs(os.Args[2:])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			fmt.Println("Usage: gnode list-remote [spec] [--channel <name>] [--lts[=<codename>]] [--major N] [--since YYYY-MM-DD] [--until YYYY-MM-DD] [--security-only] [--installable] [--all]")
 			os.Exit(1)
 		}
-		if err := mgr.ListRemote(); err != nil {
+		if err := mgr.ListRemote(opts); err != nil {
 			fmt.Printf("Error listing remote: %v\n", err)
 			os.Exit(1)
 		}
@@ -104,23 +309,202 @@ func main() {
 			os.Exit(1)
 		}
 	case "which":
-		if len(os.Args) > 2 {
-			fmt.Println("Command 'which' does not accept arguments")
+		if len(os.Args) > 3 {
+			fmt.Println("Usage: gnode which [version]")
 			os.Exit(1)
 		}
-		if err := mgr.ShowWhich(); err != nil {
+		spec := ""
+		if len(os.Args) == 3 {
+			spec = os.Args[2]
+		}
+		if err := mgr.ShowWhich(spec); err != nil {
 			fmt.Printf("Error showing path: %v\n", err)
 			os.Exit(1)
 		}
 	case "uninstall":
-		if len(os.Args) < 3 {
-			fmt.Println("Usage: gnode uninstall <version>")
+		positional, flags, err := parseArgs(os.Args[2:], []string{"prune-aliases"}, nil)
+		if err != nil || len(positional) != 1 {
+			fmt.Println("Usage: gnode uninstall <version> [--prune-aliases]")
 			os.Exit(1)
 		}
-		if err := mgr.Uninstall(os.Args[2]); err != nil {
+		_, pruneAliases := flags["prune-aliases"]
+		if err := mgr.Uninstall(positional[0], pruneAliases); err != nil {
 			fmt.Printf("Error uninstalling: %v\n", err)
 			os.Exit(1)
 		}
+	case "outdated":
+		if len(os.Args) > 2 {
+			fmt.Println("Command 'outdated' does not accept arguments")
+			os.Exit(1)
+		}
+		if err := mgr.Outdated(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	case "upgrade":
+		positional, flags, err := parseArgs(os.Args[2:], []string{"all", "reinstall-packages", "remove-old"}, nil)
+		_, all := flags["all"]
+		if err != nil || len(positional) > 1 || (all && len(positional) == 1) {
+			fmt.Println("Usage: gnode upgrade [version|--all] [--reinstall-packages] [--remove-old]")
+			os.Exit(1)
+		}
+		spec := ""
+		if len(positional) == 1 {
+			spec = positional[0]
+		}
+		_, reinstall := flags["reinstall-packages"]
+		_, removeOld := flags["remove-old"]
+		opts := manager.UpgradeOptions{All: all, ReinstallPackages: reinstall, RemoveOld: removeOld}
+		if err := mgr.Upgrade(spec, opts); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	case "eol":
+		positional, flags, err := parseArgs(os.Args[2:], []string{"update"}, nil)
+		if err != nil || len(positional) > 0 {
+			fmt.Println("Usage: gnode eol [--update]")
+			os.Exit(1)
+		}
+		_, update := flags["update"]
+		if err := mgr.EOL(update); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	case "audit":
+		positional, flags, err := parseArgs(os.Args[2:], []string{"json"}, nil)
+		if err != nil || len(positional) > 0 {
+			fmt.Println("Usage: gnode audit [--json]")
+			os.Exit(1)
+		}
+		_, asJSON := flags["json"]
+		affected, err := mgr.Audit(asJSON)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(2)
+		}
+		if affected > 0 {
+			os.Exit(1)
+		}
+	case "alias":
+		if len(os.Args) > 4 {
+			fmt.Println("Usage: gnode alias [name] [version]")
+			os.Exit(1)
+		}
+		var err error
+		switch len(os.Args) {
+		case 2:
+			err = mgr.ShowAliases("")
+		case 3:
+			err = mgr.ShowAliases(os.Args[2])
+		default:
+			err = mgr.SetAlias(os.Args[2], os.Args[3])
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	case "unalias":
+		if len(os.Args) != 3 {
+			fmt.Println("Usage: gnode unalias <name>")
+			os.Exit(1)
+		}
+		if err := mgr.RemoveAlias(os.Args[2]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	case "resolve":
+		positional, flags, err := parseArgs(os.Args[2:], []string{"installed"}, nil)
+		if err != nil || len(positional) != 1 {
+			fmt.Println("Usage: gnode resolve <spec> [--installed]")
+			os.Exit(1)
+		}
+		_, installedOnly := flags["installed"]
+		if err := mgr.Resolve(positional[0], installedOnly); err != nil {
+			fmt.Printf("Error resolving: %v\n", err)
+			os.Exit(1)
+		}
+	case "info":
+		positional, flags, err := parseArgs(os.Args[2:], []string{"json", "installed"}, nil)
+		if err != nil || len(positional) != 1 {
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+			}
+			fmt.Println("Usage: gnode info <spec> [--json] [--installed]")
+			os.Exit(1)
+		}
+		_, asJSON := flags["json"]
+		_, installedOnly := flags["installed"]
+		if err := mgr.Info(positional[0], installedOnly, asJSON); err != nil {
+			fmt.Printf("Error getting info: %v\n", err)
+			os.Exit(1)
+		}
+	case "mirror":
+		positional, flags, err := parseArgs(os.Args[2:], []string{"no-save"}, nil)
+		if err != nil || len(positional) > 2 {
+			fmt.Println("Usage: gnode mirror [list] | gnode mirror probe [channel] [--no-save]")
+			os.Exit(1)
+		}
+		sub := "list"
+		if len(positional) > 0 {
+			sub = positional[0]
+		}
+		switch {
+		case sub == "list" && len(positional) <= 1:
+			err = mgr.ShowMirrors()
+		case sub == "probe":
+			channel := ""
+			if len(positional) == 2 {
+				channel = positional[1]
+			}
+			_, noSave := flags["no-save"]
+			err = mgr.ProbeMirrors(channel, !noSave)
+		default:
+			fmt.Println("Usage: gnode mirror [list] | gnode mirror probe [channel] [--no-save]")
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	case "cache":
+		const cacheUsage = "Usage: gnode cache ls | clean [--older-than <age>] | verify | prefetch <version>"
+		positional, flags, err := parseArgs(os.Args[2:], nil, []string{"older-than"})
+		if err != nil || len(positional) == 0 {
+			fmt.Println(cacheUsage)
+			os.Exit(1)
+		}
+		olderThan, hasAge := flags["older-than"]
+		if hasAge && positional[0] != "clean" {
+			fmt.Println(cacheUsage)
+			os.Exit(1)
+		}
+		switch {
+		case positional[0] == "ls" && len(positional) == 1:
+			err = mgr.CacheList()
+		case positional[0] == "clean" && len(positional) == 1:
+			var age time.Duration
+			if hasAge {
+				if age, err = parseAge(olderThan); err != nil {
+					break
+				}
+			}
+			err = mgr.CacheClean(age)
+		case positional[0] == "verify" && len(positional) == 1:
+			corrupt, verifyErr := mgr.CacheVerify()
+			if verifyErr == nil && corrupt > 0 {
+				os.Exit(1)
+			}
+			err = verifyErr
+		case positional[0] == "prefetch" && len(positional) == 2:
+			err = mgr.Prefetch(positional[1])
+		default:
+			fmt.Println(cacheUsage)
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
 	case "status":
 		if err := mgr.Status(); err != nil {
 			fmt.Printf("Error checking status: %v\n", err)
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joaomarcosfurtado/gnode/pkg/progress"
+)
+
+const (
+	barWidth      = 24
+	plainInterval = 5 * time.Second
+)
+
+// newProgressRenderer draws a progress bar when stdout is a terminal and
+// prints a plain line every few seconds otherwise, such as in CI logs.
+func newProgressRenderer() progress.Reporter {
+	if isTerminal(os.Stdout) && os.Getenv("CI") == "" {
+		return &barRenderer{out: os.Stdout}
+	}
+	return &plainRenderer{out: os.Stdout}
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// barRenderer redraws a single line in place.
+type barRenderer struct {
+	out     io.Writer
+	lastLen int
+}
+
+func (b *barRenderer) Progress(e progress.Event) {
+	line := "  " + formatStatus(e, true)
+	padding := ""
+	if n := b.lastLen - len(line); n > 0 {
+		padding = strings.Repeat(" ", n)
+	}
+	fmt.Fprintf(b.out, "\r%s%s", line, padding)
+	b.lastLen = len(line)
+
+	if e.Done {
+		fmt.Fprintln(b.out)
+		b.lastLen = 0
+	}
+}
+
+// plainRenderer prints a line at most every plainInterval, and one when the
+// operation ends.
+type plainRenderer struct {
+	out  io.Writer
+	last time.Time
+}
+
+func (p *plainRenderer) Progress(e progress.Event) {
+	now := time.Now()
+	if !e.Done && now.Sub(p.last) < plainInterval {
+		return
+	}
+	p.last = now
+	if e.Done {
+		p.last = time.Time{}
+	}
+	fmt.Fprintf(p.out, "%s\n", formatStatus(e, false))
+}
+
+func formatStatus(e progress.Event, bar bool) string {
+	name := e.Name
+	if bar && len(name) > 32 {
+		name = name[:29] + "..."
+	}
+	parts := []string{fmt.Sprintf("%-8s %s", e.Stage, name)}
+
+	if pct := e.Percent(); pct >= 0 {
+		if bar {
+			filled := int(pct * barWidth / 100)
+			if filled > barWidth {
+				filled = barWidth
+			}
+			parts = append(parts, "["+strings.Repeat("=", filled)+strings.Repeat(" ", barWidth-filled)+"]")
+		}
+		parts = append(parts, fmt.Sprintf("%3.0f%%", pct), progress.FormatBytes(e.Bytes)+" / "+progress.FormatBytes(e.Total))
+	} else {
+		parts = append(parts, progress.FormatBytes(e.Bytes))
+	}
+
+	if e.Stage == progress.StageExtract {
+		parts = append(parts, fmt.Sprintf("%d files", e.Files))
+	} else if e.Rate > 0 {
+		parts = append(parts, progress.FormatRate(e.Rate))
+	}
+
+	switch {
+	case e.Done && e.Err != nil:
+		parts = append(parts, "stopped")
+	case e.Done:
+		parts = append(parts, "done")
+	case e.ETA >= time.Second:
+		parts = append(parts, "ETA "+e.ETA.Round(time.Second).String())
+	}
+	return strings.Join(parts, "  ")
+}
@@ -1,3 +1,14 @@
 module github.com/joaomarcosfurtado/gnode
 
 go 1.24.4
+
+require (
+	github.com/ProtonMail/go-crypto v1.4.1
+	github.com/ulikunitz/xz v0.5.17
+)
+
+require (
+	github.com/cloudflare/circl v1.6.2 // indirect
+	golang.org/x/crypto v0.41.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+)
@@ -0,0 +1,116 @@
+package alias
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/joaomarcosfurtado/gnode/internal/version"
+)
+
+const Default = "default"
+
+var validName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]*$`)
+
+type Alias struct {
+	Name string
+	Spec string
+}
+
+type Store struct {
+	dir string
+}
+
+func NewStore(dir string) *Store {
+	return &Store{
+		dir: dir,
+	}
+}
+
+func ValidateName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid alias name %q: use letters, digits, '.', '_' or '-', starting with a letter", name)
+	}
+	if version.IsAlias(name) {
+		return fmt.Errorf("%q is a built-in alias and cannot be redefined", name)
+	}
+	if _, err := version.ParseRange(name); err == nil {
+		return fmt.Errorf("%q looks like a version and cannot be used as an alias name", name)
+	}
+	return nil
+}
+
+func (s *Store) path(name string) string {
+	return filepath.Join(s.dir, name)
+}
+
+func (s *Store) Get(name string) (string, bool) {
+	if !validName.MatchString(name) {
+		return "", false
+	}
+
+	data, err := os.ReadFile(s.path(name))
+	if err != nil {
+		return "", false
+	}
+
+	spec := strings.TrimSpace(string(data))
+	if spec == "" {
+		return "", false
+	}
+	return spec, true
+}
+
+func (s *Store) Set(name, spec string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("error creating alias directory: %v", err)
+	}
+
+	if err := os.WriteFile(s.path(name), []byte(spec+"\n"), 0644); err != nil {
+		return fmt.Errorf("error writing alias %s: %v", name, err)
+	}
+	return nil
+}
+
+func (s *Store) Remove(name string) error {
+	if _, ok := s.Get(name); !ok {
+		return fmt.Errorf("alias %s does not exist", name)
+	}
+
+	if err := os.Remove(s.path(name)); err != nil {
+		return fmt.Errorf("error removing alias %s: %v", name, err)
+	}
+	return nil
+}
+
+func (s *Store) List() ([]Alias, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Alias{}, nil
+		}
+		return nil, fmt.Errorf("error reading alias directory: %v", err)
+	}
+
+	var aliases []Alias
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if spec, ok := s.Get(entry.Name()); ok {
+			aliases = append(aliases, Alias{Name: entry.Name(), Spec: spec})
+		}
+	}
+
+	sort.Slice(aliases, func(i, j int) bool {
+		return aliases[i].Name < aliases[j].Name
+	})
+	return aliases, nil
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+type Credentials struct {
+	Username string
+	Password string
+	Token    string
+}
+
+// Auth adds Authorization headers to requests for configured mirrors.
+// Credentials from the environment are only ever sent to mirror hosts the
+// user configured; netrc credentials go to the machine they name.
+type Auth struct {
+	mirrorHosts map[string]bool
+	env         Credentials
+	netrc       []netrcEntry
+}
+
+func New(mirrors []string, env Credentials, netrcPath string) (*Auth, error) {
+	a := &Auth{
+		mirrorHosts: map[string]bool{},
+		env:         env,
+	}
+
+	for _, mirror := range mirrors {
+		u, err := url.Parse(mirror)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid mirror URL %q", mirror)
+		}
+		a.mirrorHosts[u.Hostname()] = true
+	}
+
+	if netrcPath != "" {
+		entries, err := parseNetrc(netrcPath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		a.netrc = entries
+	}
+
+	return a, nil
+}
+
+func (a *Auth) Apply(req *http.Request) {
+	if a == nil || req.URL.User != nil || req.Header.Get("Authorization") != "" {
+		return
+	}
+
+	host := req.URL.Hostname()
+	isMirror := a.mirrorHosts[host]
+
+	switch {
+	case isMirror && a.env.Token != "":
+		req.Header.Set("Authorization", "Bearer "+a.env.Token)
+		return
+	case isMirror && a.env.Username != "":
+		req.SetBasicAuth(a.env.Username, a.env.Password)
+		return
+	}
+
+	var fallback *netrcEntry
+	for i := range a.netrc {
+		entry := &a.netrc[i]
+		if entry.machine == host {
+			req.SetBasicAuth(entry.login, entry.password)
+			return
+		}
+		if entry.machine == "" && fallback == nil {
+			fallback = entry
+		}
+	}
+
+	if isMirror && fallback != nil {
+		req.SetBasicAuth(fallback.login, fallback.password)
+	}
+}
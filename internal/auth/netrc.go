@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type netrcEntry struct {
+	machine  string
+	login    string
+	password string
+}
+
+// parseNetrc reads the subset of the netrc format curl and git use:
+// machine/default entries with login and password tokens. macdef bodies
+// are skipped.
+func parseNetrc(path string) ([]netrcEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var tokens []string
+	scanner := bufio.NewScanner(file)
+	inMacro := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if inMacro {
+			if strings.TrimSpace(line) == "" {
+				inMacro = false
+			}
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		for i, f := range fields {
+			if f == "macdef" {
+				inMacro = true
+				fields = fields[:i]
+				break
+			}
+		}
+		tokens = append(tokens, fields...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+
+	var entries []netrcEntry
+	var current *netrcEntry
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "machine":
+			if i+1 >= len(tokens) {
+				return entries, nil
+			}
+			entries = append(entries, netrcEntry{machine: tokens[i+1]})
+			current = &entries[len(entries)-1]
+			i++
+		case "default":
+			entries = append(entries, netrcEntry{})
+			current = &entries[len(entries)-1]
+		case "login", "password", "account":
+			if current == nil || i+1 >= len(tokens) {
+				continue
+			}
+			switch tokens[i] {
+			case "login":
+				current.login = tokens[i+1]
+			case "password":
+				current.password = tokens[i+1]
+			}
+			i++
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeNetrc(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".netrc")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseNetrc(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []netrcEntry
+	}{
+		{
+			name:    "one line",
+			content: "machine mirror.example.com login alice password s3cret\n",
+			want:    []netrcEntry{{machine: "mirror.example.com", login: "alice", password: "s3cret"}},
+		},
+		{
+			name: "multi line with comments and account",
+			content: `# corporate mirror
+machine mirror.example.com
+    login alice
+    account ignored
+    password s3cret
+
+  # another one
+machine other.example.com login bob password hunter2
+`,
+			want: []netrcEntry{
+				{machine: "mirror.example.com", login: "alice", password: "s3cret"},
+				{machine: "other.example.com", login: "bob", password: "hunter2"},
+			},
+		},
+		{
+			name:    "default entry",
+			content: "machine a.example.com login a password pa\ndefault login anon password guest\n",
+			want: []netrcEntry{
+				{machine: "a.example.com", login: "a", password: "pa"},
+				{login: "anon", password: "guest"},
+			},
+		},
+		{
+			name: "macdef body is skipped",
+			content: `machine a.example.com login a password pa macdef init
+cd /pub
+machine evil.example.com login x password y
+
+machine b.example.com login b password pb
+`,
+			want: []netrcEntry{
+				{machine: "a.example.com", login: "a", password: "pa"},
+				{machine: "b.example.com", login: "b", password: "pb"},
+			},
+		},
+		{
+			name:    "tokens before any machine are ignored",
+			content: "login stray password stray\nmachine a.example.com login a\n",
+			want:    []netrcEntry{{machine: "a.example.com", login: "a"}},
+		},
+		{
+			name:    "truncated entries",
+			content: "machine a.example.com login a password",
+			want:    []netrcEntry{{machine: "a.example.com", login: "a"}},
+		},
+		{
+			name:    "trailing machine without a name",
+			content: "machine a.example.com login a password pa machine",
+			want:    []netrcEntry{{machine: "a.example.com", login: "a", password: "pa"}},
+		},
+		{
+			name:    "empty",
+			content: "",
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseNetrc(writeNetrc(t, tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseNetrc = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNetrcMissing(t *testing.T) {
+	if _, err := parseNetrc(filepath.Join(t.TempDir(), "missing")); !os.IsNotExist(err) {
+		t.Errorf("parseNetrc of a missing file = %v, want a not-exist error", err)
+	}
+}
+
+func TestApplyNetrc(t *testing.T) {
+	path := writeNetrc(t, "machine mirror.example.com login alice password s3cret\ndefault login anon password guest\n")
+
+	tests := []struct {
+		name    string
+		mirrors []string
+		url     string
+		user    string
+		pass    string
+		ok      bool
+	}{
+		{name: "named machine", url: "https://mirror.example.com/dist/index.json", user: "alice", pass: "s3cret", ok: true},
+		{name: "default goes to mirrors", mirrors: []string{"https://other.example.com/dist"}, url: "https://other.example.com/dist/index.json", user: "anon", pass: "guest", ok: true},
+		{name: "default not sent elsewhere", url: "https://nodejs.org/dist/index.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := New(tt.mirrors, Credentials{}, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+			a.Apply(req)
+			user, pass, ok := req.BasicAuth()
+			if ok != tt.ok || user != tt.user || pass != tt.pass {
+				t.Errorf("BasicAuth = %q, %q, %v, want %q, %q, %v", user, pass, ok, tt.user, tt.pass, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/joaomarcosfurtado/gnode/internal/verify"
+)
+
+// Entry is one cached file, stored as <dir>/<sha256>/<name>. Its
+// modification time is when it was last used.
+type Entry struct {
+	Name     string
+	SHA256   string
+	Path     string
+	Size     int64
+	LastUsed time.Time
+}
+
+// Store is a content-addressed cache of downloaded files, keyed by file
+// name and SHA-256.
+type Store struct {
+	dir string
+}
+
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+func (s *Store) path(name, sum string) string {
+	return filepath.Join(s.dir, sum, name)
+}
+
+// Lookup finds the cached copy of name with the given SHA-256, or the most
+// recently used copy of name when sum is empty.
+func (s *Store) Lookup(name, sum string) (Entry, bool) {
+	if sum != "" {
+		info, err := os.Stat(s.path(name, sum))
+		if err != nil || info.IsDir() {
+			return Entry{}, false
+		}
+		return entry(s.path(name, sum), sum, info), true
+	}
+
+	entries, err := s.List()
+	if err != nil {
+		return Entry{}, false
+	}
+	var found Entry
+	ok := false
+	for _, e := range entries {
+		if e.Name == name && (!ok || e.LastUsed.After(found.LastUsed)) {
+			found, ok = e, true
+		}
+	}
+	return found, ok
+}
+
+// Add moves the file at src into the cache as name. sum is its SHA-256 when
+// already known, otherwise it is computed.
+func (s *Store) Add(src, name, sum string) (Entry, error) {
+	if sum == "" {
+		var err error
+		if sum, err = verify.FileSHA256(src); err != nil {
+			return Entry{}, fmt.Errorf("error hashing %s: %v", name, err)
+		}
+	}
+
+	dest := s.path(name, sum)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return Entry{}, fmt.Errorf("error creating cache directory: %v", err)
+	}
+	if err := os.Rename(src, dest); err != nil {
+		return Entry{}, fmt.Errorf("error adding %s to the cache: %v", name, err)
+	}
+
+	info, err := os.Stat(dest)
+	if err != nil {
+		return Entry{}, err
+	}
+	return entry(dest, sum, info), nil
+}
+
+// Touch marks an entry as used now.
+func (s *Store) Touch(e Entry) {
+	now := time.Now()
+	os.Chtimes(e.Path, now, now)
+}
+
+// Verify hashes an entry again and reports whether it still matches the
+// SHA-256 it is stored under.
+func (s *Store) Verify(e Entry) error {
+	actual, err := verify.FileSHA256(e.Path)
+	if err != nil {
+		return fmt.Errorf("error hashing %s: %v", e.Name, err)
+	}
+	if actual != e.SHA256 {
+		return fmt.Errorf("%s is corrupt: stored as sha256 %s, got %s", e.Name, e.SHA256, actual)
+	}
+	return nil
+}
+
+// Remove deletes an entry and its directory once empty.
+func (s *Store) Remove(e Entry) error {
+	if err := os.Remove(e.Path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	os.Remove(filepath.Dir(e.Path))
+	return nil
+}
+
+// List returns every entry, most recently used first.
+func (s *Store) List() ([]Entry, error) {
+	dirs, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading cache: %v", err)
+	}
+
+	var entries []Entry
+	for _, d := range dirs {
+		if !d.IsDir() || !isSHA256(d.Name()) {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(s.dir, d.Name()))
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			info, err := f.Info()
+			if err != nil || info.IsDir() {
+				continue
+			}
+			entries = append(entries, entry(filepath.Join(s.dir, d.Name(), f.Name()), d.Name(), info))
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].LastUsed.After(entries[j].LastUsed)
+	})
+	return entries, nil
+}
+
+func entry(path, sum string, info os.FileInfo) Entry {
+	return Entry{
+		Name:     info.Name(),
+		SHA256:   sum,
+		Path:     path,
+		Size:     info.Size(),
+		LastUsed: info.ModTime(),
+	}
+}
+
+func isSHA256(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
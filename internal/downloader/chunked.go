@@ -0,0 +1,247 @@
+package downloader
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/joaomarcosfurtado/gnode/internal/httpclient"
+	"github.com/joaomarcosfurtado/gnode/pkg/progress"
+)
+
+// minChunkSize keeps small files, such as SHASUMS256.txt or npm.cmd, on a
+// single connection.
+const minChunkSize = 4 << 20
+
+// chunk is one byte range of a chunked download, End inclusive. Written
+// counts the bytes from Start already in the partial file.
+type chunk struct {
+	Start   int64 `json:"start"`
+	End     int64 `json:"end"`
+	Written int64 `json:"written"`
+}
+
+func (c chunk) done() bool {
+	return c.Start+c.Written > c.End
+}
+
+// errRangeIgnored means the server answered a range request with the whole
+// file, because it does not support ranges or the file changed.
+var errRangeIgnored = errors.New("server ignored the range request")
+
+// probeRanges asks with HEAD whether url can be split into ranges. It
+// returns the metadata of a new chunked download, or false when the server
+// does not advertise byte ranges, the file is too small to be worth
+// splitting, or there is no validator to tie the ranges to one file.
+func (d *Downloader) probeRanges(url string) (partialMeta, bool) {
+	req, err := d.client.NewRequest(http.MethodHead, url)
+	if err != nil {
+		return partialMeta{}, false
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return partialMeta{}, false
+	}
+	resp.Body.Close()
+
+	meta := partialMeta{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Size:         resp.ContentLength,
+	}
+	if resp.StatusCode != http.StatusOK || !strings.Contains(resp.Header.Get("Accept-Ranges"), "bytes") ||
+		meta.Size < 2*minChunkSize || meta.validator() == "" {
+		return partialMeta{}, false
+	}
+
+	n := int64(d.connections)
+	if n > meta.Size/minChunkSize {
+		n = meta.Size / minChunkSize
+	}
+	size := meta.Size / n
+	for i := int64(0); i < n; i++ {
+		c := chunk{Start: i * size, End: (i+1)*size - 1}
+		if i == n-1 {
+			c.End = meta.Size - 1
+		}
+		meta.Chunks = append(meta.Chunks, c)
+	}
+	return meta, true
+}
+
+// fetchChunked downloads the unfinished chunks of meta concurrently, each
+// written at its offset in the partial file. Progress is saved to the
+// metadata when it stops, so the next attempt only fetches what is missing.
+// If the server turns out not to honour ranges, the download starts over
+// on a single connection. As the chunks arrive out of order, the finished
+// file is hashed once at the end.
+func (d *Downloader) fetchChunked(url, path string, meta partialMeta) (string, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", fmt.Errorf("error opening partial download: %v", err)
+	}
+	if err := file.Truncate(meta.Size); err != nil {
+		file.Close()
+		return "", fmt.Errorf("error allocating partial download: %v", err)
+	}
+	if err := writePartialMeta(path, meta); err != nil {
+		file.Close()
+		return "", fmt.Errorf("error saving download state: %v", err)
+	}
+
+	var done int64
+	pending := 0
+	for _, c := range meta.Chunks {
+		done += c.Written
+		if !c.done() {
+			pending++
+		}
+	}
+	if done > 0 {
+		fmt.Printf("Resuming at %d bytes\n", done)
+	}
+	fmt.Printf("Downloading from %s (%d connections)...\n", url, pending)
+
+	tracker := progress.NewTracker(d.progress, progress.StageDownload, fileName(url), done, meta.Size)
+	ctx, cancel := context.WithCancel(d.client.Context())
+	defer cancel()
+
+	written := make([]int64, len(meta.Chunks))
+	errs := make([]error, len(meta.Chunks))
+	var wg sync.WaitGroup
+	for i := range meta.Chunks {
+		if meta.Chunks[i].done() {
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = d.fetchChunk(ctx, url, meta, meta.Chunks[i], file, &written[i], tracker)
+			// A broken connection only costs its own chunk, which the
+			// retry resumes; anything else stops the others too.
+			var status *httpclient.StatusError
+			if errors.Is(errs[i], errRangeIgnored) || errors.As(errs[i], &status) {
+				cancel()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	closeErr := file.Close()
+	for i := range meta.Chunks {
+		meta.Chunks[i].Written += atomic.LoadInt64(&written[i])
+	}
+
+	err = firstError(errs)
+	if err == nil {
+		err = closeErr
+	}
+	if errors.Is(err, errRangeIgnored) {
+		tracker.Done(err)
+		removePartial(path)
+		fmt.Printf("%s ignored a range request, downloading on one connection\n", url)
+		return d.fetchStream(url, path, partialMeta{}, 0)
+	}
+
+	if saveErr := writePartialMeta(path, meta); saveErr != nil && err == nil {
+		err = fmt.Errorf("error saving download state: %v", saveErr)
+	}
+	if err != nil {
+		if _, ok := err.(*httpclient.StatusError); !ok {
+			err = &transferError{url: url, err: err}
+		}
+	}
+	tracker.Done(err)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	if err := hashPrefix(hash, path, meta.Size); err != nil {
+		removePartial(path)
+		return "", fmt.Errorf("error reading partial download: %v", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// fetchChunk downloads the rest of one chunk into file, counting what it
+// writes in written.
+func (d *Downloader) fetchChunk(ctx context.Context, url string, meta partialMeta, c chunk, file *os.File, written *int64, tracker *progress.Tracker) error {
+	start := c.Start + c.Written
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, c.End))
+	req.Header.Set("If-Range", meta.validator())
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		return errRangeIgnored
+	default:
+		return &httpclient.StatusError{URL: url, Code: resp.StatusCode}
+	}
+	if got, ok := contentRangeStart(resp.Header.Get("Content-Range")); !ok || got != start {
+		return errRangeIgnored
+	}
+
+	body := io.LimitReader(resp.Body, c.End-start+1)
+	buf := make([]byte, 32<<10)
+	offset := start
+	for {
+		n, readErr := body.Read(buf)
+		if n > 0 {
+			if _, err := file.WriteAt(buf[:n], offset); err != nil {
+				return err
+			}
+			offset += int64(n)
+			atomic.AddInt64(written, int64(n))
+			tracker.Add(int64(n))
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+
+	if offset <= c.End {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+// firstError returns the error that stopped a chunked download, preferring
+// a real failure over the cancellations it caused in the other chunks.
+func firstError(errs []error) error {
+	var cancelled error
+	for _, err := range errs {
+		switch {
+		case err == nil:
+		case errors.Is(err, context.Canceled):
+			if cancelled == nil {
+				cancelled = err
+			}
+		default:
+			return err
+		}
+	}
+	return cancelled
+}
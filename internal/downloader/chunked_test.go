@@ -0,0 +1,220 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestProbeRanges(t *testing.T) {
+	tests := []struct {
+		name        string
+		size        int
+		connections int
+		noRanges    bool
+		noETag      bool
+		want        []chunk
+	}{
+		{
+			name: "even split", size: 16 << 20, connections: 4,
+			want: []chunk{{0, 4<<20 - 1, 0}, {4 << 20, 8<<20 - 1, 0}, {8 << 20, 12<<20 - 1, 0}, {12 << 20, 16<<20 - 1, 0}},
+		},
+		{
+			name: "last chunk takes the remainder", size: 8<<20 + 3, connections: 2,
+			want: []chunk{{0, 4 << 20, 0}, {4<<20 + 1, 8<<20 + 2, 0}},
+		},
+		{
+			name: "chunks never shrink below the minimum", size: 10 << 20, connections: 16,
+			want: []chunk{{0, 5<<20 - 1, 0}, {5 << 20, 10<<20 - 1, 0}},
+		},
+		{name: "too small to split", size: 8<<20 - 1, connections: 4},
+		{name: "no byte ranges", size: 16 << 20, connections: 4, noRanges: true},
+		{name: "no validator", size: 16 << 20, connections: 4, noETag: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !tt.noRanges {
+					w.Header().Set("Accept-Ranges", "bytes")
+				}
+				if !tt.noETag {
+					w.Header().Set("ETag", `"v1"`)
+				}
+				w.Header().Set("Content-Length", fmt.Sprint(tt.size))
+			}))
+			defer ts.Close()
+
+			d := NewDownloader()
+			d.SetConnections(tt.connections)
+			meta, ok := d.probeRanges(ts.URL + "/node.tar.gz")
+			if ok != (tt.want != nil) {
+				t.Fatalf("probeRanges ok = %v, want %v", ok, tt.want != nil)
+			}
+			if !reflect.DeepEqual(meta.Chunks, tt.want) {
+				t.Errorf("chunks = %v, want %v", meta.Chunks, tt.want)
+			}
+			if ok && (meta.Size != int64(tt.size) || meta.ETag != `"v1"`) {
+				t.Errorf("meta = %+v", meta)
+			}
+		})
+	}
+}
+
+func chunkedDownloader(t *testing.T, connections int) (*Downloader, string) {
+	d, dest := newTestDownloader(t)
+	d.SetConnections(connections)
+	return d, dest
+}
+
+func sortedRanges(srv *fileServer) []string {
+	got := srv.requests()
+	sort.Strings(got)
+	return got
+}
+
+func TestDownloadFileChunked(t *testing.T) {
+	srv := &fileServer{body: testBody(16<<20 + 5), etag: `"v1"`}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	d, dest := chunkedDownloader(t, 4)
+	if err := d.DownloadFile(ts.URL+"/node.tar.gz", dest, checkSum(t, srv.body)); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, dest, srv.body)
+
+	want := []string{"bytes=0-4194304", "bytes=12582915-16777220", "bytes=4194305-8388609", "bytes=8388610-12582914"}
+	if got := sortedRanges(srv); !reflect.DeepEqual(got, want) {
+		t.Errorf("ranges = %q, want %q", got, want)
+	}
+}
+
+func TestDownloadFileChunkedResumes(t *testing.T) {
+	srv := &fileServer{body: testBody(16 << 20), etag: `"v1"`}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	d, dest := chunkedDownloader(t, 4)
+	url := ts.URL + "/node.tar.gz"
+
+	// The first and third chunks were partly written, the second finished
+	// and the last not started when the earlier attempt stopped.
+	const size = 4 << 20
+	data := make([]byte, len(srv.body))
+	copy(data[:1000], srv.body)
+	copy(data[size:2*size], srv.body[size:])
+	copy(data[2*size:2*size+5000], srv.body[2*size:])
+	seedPartial(t, d.partialPath(url, dest), data, partialMeta{
+		URL: url, ETag: srv.etag, Size: int64(len(srv.body)),
+		Chunks: []chunk{{0, size - 1, 1000}, {size, 2*size - 1, size}, {2 * size, 3*size - 1, 5000}, {3 * size, 4*size - 1, 0}},
+	})
+
+	if err := d.DownloadFile(url, dest, checkSum(t, srv.body)); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, dest, srv.body)
+
+	want := []string{"bytes=1000-4194303", "bytes=12582912-16777215", "bytes=8393608-12582911"}
+	if got := sortedRanges(srv); !reflect.DeepEqual(got, want) {
+		t.Errorf("ranges = %q, want %q", got, want)
+	}
+}
+
+// brokenChunkServer cuts the response to one range short the first time it
+// is asked for it.
+type brokenChunkServer struct {
+	*fileServer
+	once sync.Once
+}
+
+func (b *brokenChunkServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.Header.Get("Range"), "bytes=4194304-") {
+		broken := false
+		b.once.Do(func() { broken = true })
+		if broken {
+			b.mu.Lock()
+			b.ranges = append(b.ranges, r.Header.Get("Range"))
+			b.mu.Unlock()
+			w.Header().Set("ETag", b.etag)
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes 4194304-8388607/%d", len(b.body)))
+			w.Header().Set("Content-Length", fmt.Sprint(4<<20))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(b.body[4<<20 : 5<<20])
+			panic(http.ErrAbortHandler)
+		}
+	}
+	b.fileServer.ServeHTTP(w, r)
+}
+
+func TestDownloadFileChunkedRetriesBrokenChunk(t *testing.T) {
+	srv := &brokenChunkServer{fileServer: &fileServer{body: testBody(16 << 20), etag: `"v1"`}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	d, dest := chunkedDownloader(t, 4)
+	if err := d.DownloadFile(ts.URL+"/node.tar.gz", dest, checkSum(t, srv.body)); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, dest, srv.body)
+
+	// Only the broken chunk is fetched again, from where it stopped.
+	var second []string
+	for _, r := range srv.requests() {
+		if strings.HasSuffix(r, "-8388607") {
+			second = append(second, r)
+		}
+	}
+	if len(srv.requests()) != 5 || len(second) != 2 || second[1] == "bytes=4194304-8388607" {
+		t.Errorf("requests = %q, want the broken chunk resumed past its start", srv.requests())
+	}
+}
+
+// rangeIgnoringServer advertises ranges on HEAD but always sends the whole
+// file, as some proxies do.
+type rangeIgnoringServer struct {
+	*fileServer
+}
+
+func (s rangeIgnoringServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.Header.Del("Range")
+	s.fileServer.ServeHTTP(w, r)
+}
+
+func TestDownloadFileChunkedFallsBackWhenRangesIgnored(t *testing.T) {
+	srv := rangeIgnoringServer{&fileServer{body: testBody(16 << 20), etag: `"v1"`}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	d, dest := chunkedDownloader(t, 4)
+	if err := d.DownloadFile(ts.URL+"/node.tar.gz", dest, checkSum(t, srv.body)); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, dest, srv.body)
+}
+
+func TestFirstError(t *testing.T) {
+	failure := errors.New("connection reset")
+	cancelled := fmt.Errorf("chunk 2: %w", context.Canceled)
+
+	tests := []struct {
+		errs []error
+		want error
+	}{
+		{errs: []error{nil, nil}, want: nil},
+		{errs: []error{cancelled, nil, failure}, want: failure},
+		{errs: []error{nil, cancelled}, want: cancelled},
+	}
+	for _, tt := range tests {
+		if got := firstError(tt.errs); got != tt.want {
+			t.Errorf("firstError(%v) = %v, want %v", tt.errs, got, tt.want)
+		}
+	}
+}
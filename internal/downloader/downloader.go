@@ -1,29 +1,231 @@
 package downloader
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joaomarcosfurtado/gnode/internal/httpclient"
+	"github.com/joaomarcosfurtado/gnode/pkg/progress"
 )
 
-type Downloader struct{}
+type Downloader struct {
+	offline     bool
+	client      *httpclient.Client
+	mirrors     [][]string
+	partialDir  string
+	progress    progress.Reporter
+	connections int
+}
 
 func NewDownloader() *Downloader {
-	return &Downloader{}
+	return &Downloader{client: httpclient.Default(), connections: 1}
 }
 
-func (d *Downloader) Download(url string) (io.ReadCloser, error) {
+func (d *Downloader) SetOffline(offline bool) {
+	d.offline = offline
+}
+
+func (d *Downloader) SetClient(client *httpclient.Client) {
+	d.client = client
+}
+
+// SetPartialDir sets where DownloadFile keeps unfinished downloads so they
+// can be resumed.
+func (d *Downloader) SetPartialDir(dir string) {
+	d.partialDir = dir
+}
+
+// SetProgress makes DownloadFile report its progress to r.
+func (d *Downloader) SetProgress(r progress.Reporter) {
+	d.progress = r
+}
+
+// SetConnections sets how many connections DownloadFile may open for one
+// large file on servers that accept range requests.
+func (d *Downloader) SetConnections(n int) {
+	if n < 1 {
+		n = 1
+	}
+	d.connections = n
+}
+
+// SetMirrors registers groups of equivalent download trees. A download from
+// one mirror of a group fails over to the same path on the others.
+func (d *Downloader) SetMirrors(groups [][]string) {
+	d.mirrors = groups
+}
+
+// Candidates returns url followed by the same file on every other mirror of
+// its group, in the order they are tried.
+func (d *Downloader) Candidates(url string) []string {
+	for _, group := range d.mirrors {
+		for _, mirror := range group {
+			if !strings.HasPrefix(url, mirror+"/") {
+				continue
+			}
+
+			rest := strings.TrimPrefix(url, mirror)
+			candidates := []string{url}
+			for _, other := range group {
+				if other != mirror {
+					candidates = append(candidates, other+rest)
+				}
+			}
+			return candidates
+		}
+	}
+	return []string{url}
+}
+
+func (d *Downloader) get(url string) (io.ReadCloser, error) {
 	fmt.Printf("Downloading from %s...\n", url)
 
-	resp, err := http.Get(url)
+	req, err := d.client.NewRequest(http.MethodGet, url)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := d.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error downloading: %v", err)
+		return nil, err
 	}
 
 	if resp.StatusCode != 200 {
 		resp.Body.Close()
-		return nil, fmt.Errorf("error downloading: status %d", resp.StatusCode)
+		return nil, &httpclient.StatusError{URL: url, Code: resp.StatusCode}
 	}
 
 	return resp.Body, nil
 }
+
+// Download opens url, failing over to the other mirrors of its group on
+// connection errors and 5xx responses.
+func (d *Downloader) Download(url string) (io.ReadCloser, error) {
+	if d.offline {
+		return nil, fmt.Errorf("offline mode: cannot download %s", url)
+	}
+
+	candidates := d.Candidates(url)
+	var lastErr error
+	for i, candidate := range candidates {
+		body, err := d.get(candidate)
+		if err == nil {
+			return body, nil
+		}
+
+		lastErr = err
+		if !httpclient.ShouldFailover(err) {
+			break
+		}
+		if i < len(candidates)-1 {
+			fmt.Fprintf(os.Stderr, "Warning: %v; trying next mirror\n", err)
+		}
+	}
+
+	return nil, fmt.Errorf("error downloading: %v", lastErr)
+}
+
+// DownloadFile saves url to dest. Besides connection errors and 5xx
+// responses, it fails over to the next mirror when the transfer breaks off
+// or verify rejects the SHA-256 of the downloaded file, computed while it
+// was written. dest is only written once a copy passes verify, which may
+// be nil. Interrupted transfers are kept in the
+// partial directory and resumed by the next call for the same URL.
+func (d *Downloader) DownloadFile(url, dest string, verify func(sum string) error) error {
+	if d.offline {
+		return fmt.Errorf("offline mode: cannot download %s", url)
+	}
+
+	candidates := d.Candidates(url)
+	var lastErr error
+	for i, candidate := range candidates {
+		err := d.downloadTo(candidate, dest, verify)
+		if err == nil {
+			return nil
+		}
+
+		lastErr = err
+		if !httpclient.ShouldFailover(err) {
+			break
+		}
+		if i < len(candidates)-1 {
+			fmt.Fprintf(os.Stderr, "Warning: %v; trying next mirror\n", err)
+		}
+	}
+
+	return fmt.Errorf("error downloading: %v", lastErr)
+}
+
+func (d *Downloader) downloadTo(url, dest string, verify func(sum string) error) error {
+	partial := d.partialPath(url, dest)
+	var sum string
+	for attempt := 0; ; attempt++ {
+		var err error
+		sum, err = d.fetchPartial(url, partial)
+		if err == nil {
+			break
+		}
+
+		var transfer *transferError
+		if !errors.As(err, &transfer) || attempt == httpclient.MaxRetries || !httpclient.Transient(transfer.err) {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v; retrying\n", err)
+		if err := d.client.Wait(d.client.Context(), attempt); err != nil {
+			return err
+		}
+	}
+
+	if verify != nil {
+		if err := verify(sum); err != nil {
+			// A corrupt file must not be resumed either.
+			removePartial(partial)
+			return fmt.Errorf("%s: %v", url, err)
+		}
+	}
+
+	if err := moveFile(partial, dest); err != nil {
+		return fmt.Errorf("error saving %s: %v", filepath.Base(dest), err)
+	}
+	os.Remove(partial + ".json")
+	return nil
+}
+
+// moveFile renames src to dest, copying when they are on different file
+// systems.
+func moveFile(src, dest string) error {
+	if err := os.Rename(src, dest); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dest), filepath.Base(dest)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
+}
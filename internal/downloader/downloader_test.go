@@ -0,0 +1,168 @@
+package downloader
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fileServer serves body with an ETag and byte ranges, as a mirror does,
+// and records the Range header of every GET.
+type fileServer struct {
+	body []byte
+	etag string
+
+	mu     sync.Mutex
+	ranges []string
+}
+
+func (f *fileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		f.mu.Lock()
+		f.ranges = append(f.ranges, r.Header.Get("Range"))
+		f.mu.Unlock()
+	}
+	w.Header().Set("ETag", f.etag)
+	http.ServeContent(w, r, "node.tar.gz", time.Time{}, bytes.NewReader(f.body))
+}
+
+func (f *fileServer) requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.ranges...)
+}
+
+func testBody(size int) []byte {
+	body := make([]byte, size)
+	for i := range body {
+		body[i] = byte(i * 7 % 251)
+	}
+	return body
+}
+
+func newTestDownloader(t *testing.T) (*Downloader, string) {
+	t.Helper()
+	d := NewDownloader()
+	d.SetPartialDir(filepath.Join(t.TempDir(), "partial"))
+	return d, filepath.Join(t.TempDir(), "node.tar.gz")
+}
+
+func checkFile(t *testing.T, path string, want []byte) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("downloaded %d bytes that differ from the %d served", len(got), len(want))
+	}
+}
+
+// checkSum returns a verify func that fails the test unless it is given the
+// SHA-256 of want.
+func checkSum(t *testing.T, want []byte) func(string) error {
+	sum := sha256.Sum256(want)
+	return func(got string) error {
+		if got != hex.EncodeToString(sum[:]) {
+			t.Errorf("verify got sha256 %s, want %x", got, sum)
+		}
+		return nil
+	}
+}
+
+func seedPartial(t *testing.T, path string, data []byte, meta partialMeta) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writePartialMeta(path, meta); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDownloadFileResumes(t *testing.T) {
+	srv := &fileServer{body: testBody(100 << 10), etag: `"v1"`}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	d, dest := newTestDownloader(t)
+	url := ts.URL + "/node.tar.gz"
+	seedPartial(t, d.partialPath(url, dest), srv.body[:40<<10], partialMeta{URL: url, ETag: srv.etag})
+
+	if err := d.DownloadFile(url, dest, checkSum(t, srv.body)); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, dest, srv.body)
+	if got := srv.requests(); len(got) != 1 || got[0] != "bytes=40960-" {
+		t.Errorf("requests = %q, want one resuming at 40960", got)
+	}
+}
+
+func TestDownloadFileRestartsWhenRemoteChanged(t *testing.T) {
+	srv := &fileServer{body: testBody(100 << 10), etag: `"v2"`}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	d, dest := newTestDownloader(t)
+	url := ts.URL + "/node.tar.gz"
+	seedPartial(t, d.partialPath(url, dest), bytes.Repeat([]byte("x"), 40<<10), partialMeta{URL: url, ETag: `"v1"`})
+
+	if err := d.DownloadFile(url, dest, checkSum(t, srv.body)); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, dest, srv.body)
+}
+
+// A partial file that is already complete, left by a process stopped before
+// it verified and moved it, gets a 416 when resumed and is fetched again.
+func TestDownloadFileRestartsCompletePartial(t *testing.T) {
+	srv := &fileServer{body: testBody(100 << 10), etag: `"v1"`}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	d, dest := newTestDownloader(t)
+	url := ts.URL + "/node.tar.gz"
+	partial := d.partialPath(url, dest)
+	seedPartial(t, partial, srv.body, partialMeta{URL: url, ETag: srv.etag})
+
+	if err := d.DownloadFile(url, dest, checkSum(t, srv.body)); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, dest, srv.body)
+	if got := srv.requests(); len(got) != 2 || got[0] != "bytes=102400-" || got[1] != "" {
+		t.Errorf("requests = %q, want a refused resume then a full download", got)
+	}
+	if _, err := os.Stat(partial); !os.IsNotExist(err) {
+		t.Errorf("partial file left behind: %v", err)
+	}
+}
+
+func TestDownloadFileVerifyFailureDiscardsPartial(t *testing.T) {
+	srv := &fileServer{body: testBody(10 << 10), etag: `"v1"`}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	d, dest := newTestDownloader(t)
+	url := ts.URL + "/node.tar.gz"
+	err := d.DownloadFile(url, dest, func(string) error { return os.ErrInvalid })
+	if err == nil || !strings.Contains(err.Error(), os.ErrInvalid.Error()) {
+		t.Fatalf("DownloadFile = %v, want the verification error", err)
+	}
+	if _, err := os.Stat(d.partialPath(url, dest)); !os.IsNotExist(err) {
+		t.Errorf("partial file kept after failed verification: %v", err)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Errorf("destination written after failed verification: %v", err)
+	}
+}
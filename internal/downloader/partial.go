@@ -0,0 +1,239 @@
+package downloader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/joaomarcosfurtado/gnode/internal/httpclient"
+	"github.com/joaomarcosfurtado/gnode/pkg/progress"
+)
+
+// partialMeta records where an interrupted download came from and the
+// validators the server sent, so it is only resumed against the same file.
+type partialMeta struct {
+	URL          string  `json:"url"`
+	ETag         string  `json:"etag,omitempty"`
+	LastModified string  `json:"last_modified,omitempty"`
+	Size         int64   `json:"size,omitempty"`
+	Chunks       []chunk `json:"chunks,omitempty"` // set for chunked downloads
+}
+
+// validator is the If-Range value that proves the remote file is unchanged.
+// Weak ETags cannot be used for ranges.
+func (p partialMeta) validator() string {
+	if p.ETag != "" && !strings.HasPrefix(p.ETag, "W/") {
+		return p.ETag
+	}
+	return p.LastModified
+}
+
+// partialPath names the partial file of a URL. The hash keeps files with
+// the same name from different releases (win-x64/node.exe) apart.
+func (d *Downloader) partialPath(url, dest string) string {
+	dir := d.partialDir
+	if dir == "" {
+		dir = filepath.Dir(dest)
+	}
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(dir, filepath.Base(dest)+"."+hex.EncodeToString(sum[:6])+".part")
+}
+
+func readPartialMeta(path string) (partialMeta, bool) {
+	var meta partialMeta
+	data, err := os.ReadFile(path + ".json")
+	if err != nil || json.Unmarshal(data, &meta) != nil {
+		return meta, false
+	}
+	return meta, true
+}
+
+func writePartialMeta(path string, meta partialMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path+".json", data, 0644)
+}
+
+func removePartial(path string) {
+	os.Remove(path)
+	os.Remove(path + ".json")
+}
+
+// fetchPartial downloads url into the partial file at path and returns its
+// SHA-256, resuming from what an earlier attempt left there when the server
+// confirms with If-Range that the file has not changed. Large files are
+// fetched over several connections when the server accepts ranges. A failed
+// transfer leaves the partial file in place for the next attempt.
+func (d *Downloader) fetchPartial(url, path string) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("error creating partial download directory: %v", err)
+	}
+
+	meta, ok := readPartialMeta(path)
+	info, statErr := os.Stat(path)
+	resumable := ok && statErr == nil && meta.URL == url && meta.validator() != ""
+
+	if resumable && len(meta.Chunks) > 0 {
+		return d.fetchChunked(url, path, meta)
+	}
+	if resumable {
+		return d.fetchStream(url, path, meta, info.Size())
+	}
+
+	if d.connections > 1 {
+		if meta, ok := d.probeRanges(url); ok {
+			return d.fetchChunked(url, path, meta)
+		}
+	}
+	return d.fetchStream(url, path, partialMeta{}, 0)
+}
+
+// fetchStream downloads url over one connection, appending to the first
+// offset bytes of the partial file when meta still describes the remote
+// file. The file is hashed as it is written, its kept prefix read once.
+func (d *Downloader) fetchStream(url, path string, meta partialMeta, offset int64) (string, error) {
+	req, err := d.client.NewRequest(http.MethodGet, url)
+	if err != nil {
+		return "", err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", meta.validator())
+	}
+
+	fmt.Printf("Downloading from %s...\n", url)
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	done := int64(0)
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if start, ok := contentRangeStart(resp.Header.Get("Content-Range")); !ok || start != offset {
+			removePartial(path)
+			return "", fmt.Errorf("%s sent an unexpected range %q", url, resp.Header.Get("Content-Range"))
+		}
+		fmt.Printf("Resuming at %d bytes\n", offset)
+		flags |= os.O_APPEND
+		done = offset
+	case http.StatusOK:
+		if offset > 0 {
+			fmt.Printf("Remote file changed since the interrupted download, starting over\n")
+		}
+		flags |= os.O_TRUNC
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The partial file is already complete, as when gnode stopped
+		// before verifying it, or longer than the remote one. Either way
+		// it is fetched again from the start.
+		if offset == 0 {
+			return "", &httpclient.StatusError{URL: url, Code: resp.StatusCode}
+		}
+		resp.Body.Close()
+		removePartial(path)
+		fmt.Printf("Interrupted download of %s cannot be resumed, starting over\n", fileName(url))
+		return d.fetchStream(url, path, partialMeta{}, 0)
+	default:
+		return "", &httpclient.StatusError{URL: url, Code: resp.StatusCode}
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		meta = partialMeta{
+			URL:          url,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		}
+		if err := writePartialMeta(path, meta); err != nil {
+			return "", fmt.Errorf("error saving download state: %v", err)
+		}
+	}
+
+	hash := sha256.New()
+	if done > 0 {
+		if err := hashPrefix(hash, path, done); err != nil {
+			removePartial(path)
+			return "", fmt.Errorf("error reading partial download: %v", err)
+		}
+	}
+
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return "", fmt.Errorf("error opening partial download: %v", err)
+	}
+
+	total := int64(0)
+	if resp.ContentLength > 0 {
+		total = done + resp.ContentLength
+	}
+	tracker := progress.NewTracker(d.progress, progress.StageDownload, fileName(req.URL.Path), done, total)
+
+	_, err = io.Copy(io.MultiWriter(file, hash), tracker.Reader(resp.Body))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		err = &transferError{url: url, err: err}
+	}
+	tracker.Done(err)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// hashPrefix feeds the first n bytes of the file at path to hash.
+func hashPrefix(hash io.Writer, path string, n int64) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.CopyN(hash, file, n)
+	return err
+}
+
+// transferError is a download that broke off after the response started.
+// What arrived is kept, so retrying resumes from there.
+type transferError struct {
+	url string
+	err error
+}
+
+func (e *transferError) Error() string {
+	return fmt.Sprintf("error reading %s: %v (the download resumes on the next attempt)", e.url, e.err)
+}
+
+func (e *transferError) Unwrap() error {
+	return e.err
+}
+
+// fileName returns the last element of a URL path.
+func fileName(urlPath string) string {
+	return urlPath[strings.LastIndex(urlPath, "/")+1:]
+}
+
+// contentRangeStart parses the first byte position of "bytes 100-199/200".
+func contentRangeStart(header string) (int64, bool) {
+	rest, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, false
+	}
+	start, _, ok := strings.Cut(rest, "-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(start, 10, 64)
+	return n, err == nil
+}
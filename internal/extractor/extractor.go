@@ -3,29 +3,96 @@ package extractor
 import (
 	"archive/tar"
 	"archive/zip"
+	"bufio"
 	"compress/gzip"
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+
+	"github.com/joaomarcosfurtado/gnode/pkg/progress"
+	"github.com/ulikunitz/xz"
 )
 
-type Extractor struct{}
+type Extractor struct {
+	ctx      context.Context
+	progress progress.Reporter
+}
 
 func NewExtractor() *Extractor {
-	return &Extractor{}
+	return &Extractor{ctx: context.Background()}
+}
+
+// SetContext makes extraction stop between entries once ctx is cancelled.
+func (e *Extractor) SetContext(ctx context.Context) {
+	e.ctx = ctx
+}
+
+// SetProgress makes extraction report its progress to r. Bytes count the
+// compressed archive read so far.
+func (e *Extractor) SetProgress(r progress.Reporter) {
+	e.progress = r
 }
 
 func (e *Extractor) ExtractTarGz(reader io.Reader, destDir string) error {
-	gzr, err := gzip.NewReader(reader)
+	return e.extractTarball(reader, destDir, func(r io.Reader) (io.Reader, error) {
+		gzr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("error creating reader gzip: %v", err)
+		}
+		return gzr, nil
+	})
+}
+
+// ExtractTarXz extracts a .tar.xz archive, decompressed in pure Go.
+func (e *Extractor) ExtractTarXz(reader io.Reader, destDir string) error {
+	return e.extractTarball(reader, destDir, func(r io.Reader) (io.Reader, error) {
+		xzr, err := xz.NewReader(bufio.NewReader(r))
+		if err != nil {
+			return nil, fmt.Errorf("error creating reader xz: %v", err)
+		}
+		return xzr, nil
+	})
+}
+
+// extractTarball extracts a compressed tar archive, opening the
+// decompressed stream with decompress.
+func (e *Extractor) extractTarball(reader io.Reader, destDir string, decompress func(io.Reader) (io.Reader, error)) error {
+	name, total := "archive", int64(0)
+	if file, ok := reader.(*os.File); ok {
+		name = filepath.Base(file.Name())
+		if info, err := file.Stat(); err == nil {
+			total = info.Size()
+		}
+	}
+
+	tracker := progress.NewTracker(e.progress, progress.StageExtract, name, 0, total)
+	err := e.extractTar(tracker.Reader(reader), destDir, decompress, tracker)
+	tracker.Done(err)
+	return err
+}
+
+func (e *Extractor) extractTar(reader io.Reader, destDir string, decompress func(io.Reader) (io.Reader, error), tracker *progress.Tracker) error {
+	decompressed, err := decompress(reader)
 	if err != nil {
-		return fmt.Errorf("error creating reader gzip: %v", err)
+		return err
 	}
-	defer gzr.Close()
 
-	tr := tar.NewReader(gzr)
+	root, err := openDest(destDir)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	tr := tar.NewReader(decompressed)
 	for {
+		if err := e.ctx.Err(); err != nil {
+			return err
+		}
+
 		header, err := tr.Next()
 		if err == io.EOF {
 			break
@@ -34,31 +101,144 @@ func (e *Extractor) ExtractTarGz(reader io.Reader, destDir string) error {
 			return fmt.Errorf("error reading header from tar: %v", err)
 		}
 
-		pathParts := strings.Split(header.Name, "/")
-		if len(pathParts) <= 1 {
-			continue
+		path, err := entryPath(header.Name, destDir)
+		if err != nil {
+			return err
 		}
-
-		relativePath := strings.Join(pathParts[1:], "/")
-		if relativePath == "" {
+		if path == "" {
 			continue
 		}
 
-		path := filepath.Join(destDir, relativePath)
 		switch header.Typeflag {
 		case tar.TypeDir:
-			if err := os.MkdirAll(path, os.FileMode(header.Mode)); err != nil {
+			if err := mkdirAll(root, path, os.FileMode(header.Mode).Perm()); err != nil {
 				return fmt.Errorf("error creating directory: %v", err)
 			}
 		case tar.TypeReg:
-			if err := e.extractFile(tr, path, header.Mode); err != nil {
+			if err := e.extractFile(root, tr, path, header.Mode); err != nil {
 				return fmt.Errorf("error extracting file %v", err)
 			}
+			tracker.AddFile()
+		case tar.TypeSymlink:
+			if err := extractSymlink(root, header.Linkname, path); err != nil {
+				return fmt.Errorf("error extracting link %s: %v", header.Name, err)
+			}
+			tracker.AddFile()
+		}
+	}
+	return nil
+}
+
+// openDest creates destDir and opens it as the root every entry is written
+// under, so that no entry, even one reached through a symlink extracted
+// earlier, lands outside it.
+func openDest(destDir string) (*os.Root, error) {
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating directory: %v", err)
+	}
+	root, err := os.OpenRoot(destDir)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s: %v", destDir, err)
+	}
+	return root, nil
+}
+
+// entryPath returns where an archive entry goes relative to destDir, with
+// the archive's top-level directory stripped, or "" for entries to skip.
+func entryPath(name, destDir string) (string, error) {
+	parts := strings.Split(name, "/")
+	if len(parts) <= 1 {
+		return "", nil
+	}
+
+	rel := path.Clean(strings.Join(parts[1:], "/"))
+	switch {
+	case rel == ".":
+		return "", nil
+	case rel == ".." || strings.HasPrefix(rel, "../") || path.IsAbs(rel):
+		return "", fmt.Errorf("archive entry %s points outside %s", name, destDir)
+	}
+	return filepath.FromSlash(rel), nil
+}
+
+// mkdirAll creates dir below root one component at a time, refusing to go
+// through anything but a real directory, such as a symlink.
+func mkdirAll(root *os.Root, dir string, mode os.FileMode) error {
+	current := ""
+	for _, part := range strings.Split(dir, string(filepath.Separator)) {
+		if part == "." || part == "" {
+			continue
+		}
+		current = filepath.Join(current, part)
+
+		info, err := root.Lstat(current)
+		if os.IsNotExist(err) {
+			if err := root.Mkdir(current, mode|0700); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", current)
 		}
 	}
 	return nil
 }
 
+// extractSymlink creates the link at path, such as bin/npm pointing to
+// ../lib/node_modules/npm/bin/npm-cli.js.
+func extractSymlink(root *os.Root, target, path string) error {
+	if err := checkLinkTarget(root, filepath.Dir(path), target); err != nil {
+		return err
+	}
+	if err := mkdirAll(root, filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := root.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	// os.Root cannot create links; the parents were checked above to be
+	// real directories below the root.
+	return os.Symlink(target, filepath.Join(root.Name(), path))
+}
+
+// checkLinkTarget refuses a link target, read from the link's directory
+// dir, that leaves root or passes through another link, whose own target
+// could lead anywhere.
+func checkLinkTarget(root *os.Root, dir, target string) error {
+	if filepath.IsAbs(target) || strings.HasPrefix(target, "/") {
+		return fmt.Errorf("target %s is outside %s", target, root.Name())
+	}
+
+	var parts []string
+	for _, part := range strings.Split(filepath.ToSlash(dir), "/") {
+		if part != "." && part != "" {
+			parts = append(parts, part)
+		}
+	}
+	for _, part := range strings.Split(filepath.ToSlash(target), "/") {
+		switch part {
+		case "", ".":
+		case "..":
+			if len(parts) == 0 {
+				return fmt.Errorf("target %s is outside %s", target, root.Name())
+			}
+			parts = parts[:len(parts)-1]
+		default:
+			parts = append(parts, part)
+			current := filepath.Join(parts...)
+			if info, err := root.Lstat(current); err == nil && info.Mode()&os.ModeSymlink != 0 {
+				return fmt.Errorf("target %s goes through the link %s", target, current)
+			}
+		}
+	}
+	return nil
+}
+
+// ExtractZip extracts src into destDir. Progress counts uncompressed bytes.
 func (e *Extractor) ExtractZip(src, destDir string) error {
 	reader, err := zip.OpenReader(src)
 	if err != nil {
@@ -66,36 +246,55 @@ func (e *Extractor) ExtractZip(src, destDir string) error {
 	}
 	defer reader.Close()
 
+	var total int64
 	for _, file := range reader.File {
-		pathParts := strings.Split(file.Name, "/")
-		if len(pathParts) <= 1 {
-			continue
+		total += int64(file.UncompressedSize64)
+	}
+	tracker := progress.NewTracker(e.progress, progress.StageExtract, filepath.Base(src), 0, total)
+	err = e.extractZip(reader, destDir, tracker)
+	tracker.Done(err)
+	return err
+}
+
+func (e *Extractor) extractZip(reader *zip.ReadCloser, destDir string, tracker *progress.Tracker) error {
+	root, err := openDest(destDir)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+
+	for _, file := range reader.File {
+		if err := e.ctx.Err(); err != nil {
+			return err
 		}
 
-		relativePath := strings.Join(pathParts[1:], "/")
-		if relativePath == "" {
+		path, err := entryPath(file.Name, destDir)
+		if err != nil {
+			return err
+		}
+		if path == "" {
 			continue
 		}
 
-		path := filepath.Join(destDir, relativePath)
-
 		if file.FileInfo().IsDir() {
-			if err := os.MkdirAll(path, file.FileInfo().Mode()); err != nil {
+			if err := mkdirAll(root, path, file.FileInfo().Mode().Perm()); err != nil {
 				return fmt.Errorf("error creating directory: %v", err)
 			}
 			continue
 		}
 
-		if err := e.extractZipFile(file, path); err != nil {
+		if err := e.extractZipFile(root, file, path); err != nil {
 			return fmt.Errorf("error extracting file %s: %v", file.Name, err)
 		}
+		tracker.Add(int64(file.UncompressedSize64))
+		tracker.AddFile()
 	}
 
 	return nil
 }
 
-func (e *Extractor) extractZipFile(file *zip.File, destPath string) error {
-	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+func (e *Extractor) extractZipFile(root *os.Root, file *zip.File, path string) error {
+	if err := mkdirAll(root, filepath.Dir(path), 0755); err != nil {
 		return err
 	}
 
@@ -105,7 +304,7 @@ func (e *Extractor) extractZipFile(file *zip.File, destPath string) error {
 	}
 	defer rc.Close()
 
-	outFile, err := os.OpenFile(destPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, file.FileInfo().Mode())
+	outFile, err := root.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, file.FileInfo().Mode().Perm())
 	if err != nil {
 		return err
 	}
@@ -115,12 +314,12 @@ func (e *Extractor) extractZipFile(file *zip.File, destPath string) error {
 	return err
 }
 
-func (e *Extractor) extractFile(tr *tar.Reader, path string, mode int64) error {
-	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+func (e *Extractor) extractFile(root *os.Root, tr *tar.Reader, path string, mode int64) error {
+	if err := mkdirAll(root, filepath.Dir(path), 0755); err != nil {
 		return err
 	}
 
-	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(mode))
+	file, err := root.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(mode).Perm())
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,168 @@
+package extractor
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ulikunitz/xz"
+)
+
+type entry struct {
+	name, body, link string
+	dir              bool
+}
+
+func writeTar(t *testing.T, w io.Writer, entries []entry) {
+	t.Helper()
+	tw := tar.NewWriter(w)
+	for _, e := range entries {
+		h := &tar.Header{Name: e.name, Mode: 0755, Typeflag: tar.TypeReg, Size: int64(len(e.body))}
+		switch {
+		case e.dir:
+			h.Typeflag, h.Size = tar.TypeDir, 0
+		case e.link != "":
+			h.Typeflag, h.Linkname, h.Size = tar.TypeSymlink, e.link, 0
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func tarGz(t *testing.T, entries []entry) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	writeTar(t, gw, entries)
+	gw.Close()
+	return buf.Bytes()
+}
+
+func tarXz(t *testing.T, entries []entry) []byte {
+	var buf bytes.Buffer
+	xw, err := xz.NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTar(t, xw, entries)
+	xw.Close()
+	return buf.Bytes()
+}
+
+// nodeTarball is laid out like the official Linux and macOS builds, with
+// bin/npm a relative symlink into lib.
+var nodeTarball = []entry{
+	{name: "node-v20.12.0-linux-x64/", dir: true},
+	{name: "node-v20.12.0-linux-x64/bin/", dir: true},
+	{name: "node-v20.12.0-linux-x64/bin/node", body: "node"},
+	{name: "node-v20.12.0-linux-x64/lib/node_modules/npm/bin/npm-cli.js", body: "npm"},
+	{name: "node-v20.12.0-linux-x64/bin/npm", link: "../lib/node_modules/npm/bin/npm-cli.js"},
+}
+
+func TestExtractTarballs(t *testing.T) {
+	for name, data := range map[string][]byte{"gz": tarGz(t, nodeTarball), "xz": tarXz(t, nodeTarball)} {
+		t.Run(name, func(t *testing.T) {
+			dest := t.TempDir()
+			e := NewExtractor()
+			extract := e.ExtractTarGz
+			if name == "xz" {
+				extract = e.ExtractTarXz
+			}
+			if err := extract(bytes.NewReader(data), dest); err != nil {
+				t.Fatal(err)
+			}
+
+			if got, err := os.ReadFile(filepath.Join(dest, "bin", "node")); err != nil || string(got) != "node" {
+				t.Errorf("bin/node = %q, %v", got, err)
+			}
+			link, err := os.Readlink(filepath.Join(dest, "bin", "npm"))
+			if err != nil || link != "../lib/node_modules/npm/bin/npm-cli.js" {
+				t.Errorf("bin/npm links to %q, %v", link, err)
+			}
+			if got, err := os.ReadFile(filepath.Join(dest, "bin", "npm")); err != nil || string(got) != "npm" {
+				t.Errorf("bin/npm resolves to %q, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestExtractTarRejectsEscapes(t *testing.T) {
+	tests := map[string][]entry{
+		"absolute link":  {{name: "node/bin/npm", link: "/etc/passwd"}},
+		"relative link":  {{name: "node/bin/npm", link: "../../../etc/passwd"}},
+		"dot-dot entry":  {{name: "node/../../evil", body: "x"}},
+		"link to parent": {{name: "node/up", link: ".."}},
+		"chained links": {
+			{name: "node/d/", dir: true},
+			{name: "node/d/e", link: ".."},
+			{name: "node/f", link: "d/e/.."},
+			{name: "node/f/evil", body: "x"},
+		},
+		"file through a link": {
+			{name: "node/d/", dir: true},
+			{name: "node/d/e", link: ".."},
+			{name: "node/d/e/evil", body: "x"},
+		},
+	}
+
+	for name, entries := range tests {
+		t.Run(name, func(t *testing.T) {
+			parent := t.TempDir()
+			dest := filepath.Join(parent, "v20")
+			if err := NewExtractor().ExtractTarGz(bytes.NewReader(tarGz(t, entries)), dest); err == nil {
+				t.Error("ExtractTarGz succeeded, want an error")
+			}
+
+			found, _ := os.ReadDir(parent)
+			for _, f := range found {
+				if f.Name() != "v20" {
+					t.Errorf("%s written outside the destination", f.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestExtractZipRejectsEscapes(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"node/node.exe", "node/../../evil"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte("x"))
+	}
+	zw.Close()
+
+	parent := t.TempDir()
+	src := filepath.Join(parent, "node.zip")
+	if err := os.WriteFile(src, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(parent, "v20")
+	if err := NewExtractor().ExtractZip(src, dest); err == nil || !strings.Contains(err.Error(), "outside") {
+		t.Errorf("ExtractZip = %v, want an error about escaping the destination", err)
+	}
+	if _, err := os.Stat(filepath.Join(parent, "evil")); !os.IsNotExist(err) {
+		t.Errorf("evil written outside the destination: %v", err)
+	}
+}
+
+func TestExtractTarXzCorrupt(t *testing.T) {
+	if err := NewExtractor().ExtractTarXz(strings.NewReader("not xz"), t.TempDir()); err == nil {
+		t.Error("ExtractTarXz of garbage should fail")
+	}
+}
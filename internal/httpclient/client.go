@@ -0,0 +1,295 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/joaomarcosfurtado/gnode/internal/auth"
+)
+
+const (
+	// ConnectTimeout bounds DNS lookup, TCP connect and the TLS handshake.
+	ConnectTimeout = 15 * time.Second
+	// IdleTimeout bounds how long a request may wait for response headers
+	// or for the next bytes of a body before it is considered stalled.
+	IdleTimeout = 30 * time.Second
+	// MaxRetries is how many times a transient failure is retried.
+	MaxRetries = 3
+
+	baseBackoff = 500 * time.Millisecond
+)
+
+var errStalled = fmt.Errorf("no data received for %v", IdleTimeout)
+
+// Client is the HTTP client shared by every request gnode makes. It adds
+// the User-Agent and mirror credentials, retries transient failures with
+// exponential backoff and aborts stalled transfers. All requests are bound
+// to the context given to New, so cancelling it stops them.
+type Client struct {
+	ctx     context.Context
+	http    *http.Client
+	auth    *auth.Auth
+	backoff time.Duration
+}
+
+// Options configures authentication and TLS for a Client. The zero value
+// uses the system roots and the proxy from HTTPS_PROXY/HTTP_PROXY.
+type Options struct {
+	Auth       *auth.Auth
+	CACert     string // PEM bundle trusted in addition to the system roots
+	ClientCert string // PEM certificate for mutual TLS, with ClientKey
+	ClientKey  string
+	Proxy      string // proxy URL, credentials included
+	Insecure   bool   // skip certificate verification
+}
+
+func New(ctx context.Context, opts Options) (*Client, error) {
+	tlsConfig, err := opts.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if opts.Proxy != "" {
+		proxyURL, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %v", err)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	dialer := &net.Dialer{Timeout: ConnectTimeout, KeepAlive: 30 * time.Second}
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   ConnectTimeout,
+		IdleConnTimeout:       90 * time.Second,
+		ExpectContinueTimeout: time.Second,
+		MaxIdleConnsPerHost:   4,
+		ForceAttemptHTTP2:     true,
+	}
+
+	return &Client{
+		ctx:     ctx,
+		http:    &http.Client{Transport: transport},
+		auth:    opts.Auth,
+		backoff: baseBackoff,
+	}, nil
+}
+
+// Default returns a client without credentials or custom TLS settings.
+func Default() *Client {
+	client, _ := New(context.Background(), Options{})
+	return client
+}
+
+func (o Options) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{InsecureSkipVerify: o.Insecure}
+
+	if o.CACert != "" {
+		pem, err := os.ReadFile(o.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA bundle: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", o.CACert)
+		}
+		config.RootCAs = pool
+	}
+
+	if o.ClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(o.ClientCert, o.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// Context returns the context requests are bound to.
+func (c *Client) Context() context.Context {
+	return c.ctx
+}
+
+// NewRequest creates a request bound to the client's context.
+func (c *Client) NewRequest(method, url string) (*http.Request, error) {
+	return http.NewRequestWithContext(c.ctx, method, url, nil)
+}
+
+// UserAgent identifies gnode, its version and platform to servers.
+func UserAgent() string {
+	v := "dev"
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		v = info.Main.Version
+	}
+	return fmt.Sprintf("gnode/%s (%s; %s)", v, runtime.GOOS, runtime.GOARCH)
+}
+
+// Do sends req, retrying connection errors and 429, 502, 503 and 504
+// responses. Other statuses are returned to the caller as they are.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	return c.do(req, MaxRetries)
+}
+
+// DoOnce sends req without retrying, for callers that measure the request.
+func (c *Client) DoOnce(req *http.Request) (*http.Response, error) {
+	return c.do(req, 0)
+}
+
+func (c *Client) do(req *http.Request, retries int) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(req)
+		if err == nil && (!retryableStatus(resp.StatusCode) || attempt == retries) {
+			return resp, nil
+		}
+		if err != nil && (attempt == retries || !Transient(err)) {
+			return nil, err
+		}
+
+		if err == nil {
+			resp.Body.Close()
+			err = fmt.Errorf("%s returned status %d", req.URL, resp.StatusCode)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v; retrying\n", err)
+		if err := c.Wait(req.Context(), attempt); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// send makes one attempt. The request is cancelled when neither headers nor
+// body bytes arrive for IdleTimeout.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancelCause(req.Context())
+	watchdog := time.AfterFunc(IdleTimeout, func() { cancel(errStalled) })
+
+	attempt := req.Clone(ctx)
+	attempt.Header.Set("User-Agent", UserAgent())
+	c.auth.Apply(attempt)
+
+	resp, err := c.http.Do(attempt)
+	if err != nil {
+		watchdog.Stop()
+		cancel(nil)
+		if context.Cause(ctx) == errStalled {
+			return nil, fmt.Errorf("%s: %v", req.URL, errStalled)
+		}
+		return nil, err
+	}
+
+	resp.Body = &idleBody{body: resp.Body, ctx: ctx, cancel: cancel, watchdog: watchdog}
+	return resp, nil
+}
+
+// Wait sleeps before retry number attempt (counting from 0), doubling the
+// delay each time. It returns early with an error when ctx is cancelled.
+func (c *Client) Wait(ctx context.Context, attempt int) error {
+	timer := time.NewTimer(c.backoff << attempt)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// Transient reports whether err may go away when the request is repeated:
+// dropped connections and timeouts, but not cancellation, unknown hosts or
+// certificate errors on either side.
+func Transient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTemporary || dnsErr.IsTimeout
+	}
+
+	var certErr *tls.CertificateVerificationError
+	if errors.As(err, &certErr) {
+		return false
+	}
+	// A TLS alert from the server, such as a missing client certificate.
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "remote error" {
+		return false
+	}
+	return true
+}
+
+// StatusError is a response with an unexpected HTTP status.
+type StatusError struct {
+	URL  string
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s returned status %d", e.URL, e.Code)
+}
+
+// ShouldFailover reports whether another mirror may succeed where this one
+// failed: connection errors and 5xx responses, but not a client error such
+// as 404 or 401 or a cancelled request.
+func ShouldFailover(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var status *StatusError
+	if errors.As(err, &status) {
+		return status.Code >= 500
+	}
+	return true
+}
+
+// idleBody resets the stall watchdog on every read and releases the
+// request context when closed.
+type idleBody struct {
+	body     io.ReadCloser
+	ctx      context.Context
+	cancel   context.CancelCauseFunc
+	watchdog *time.Timer
+}
+
+func (b *idleBody) Read(p []byte) (int, error) {
+	b.watchdog.Reset(IdleTimeout)
+	n, err := b.body.Read(p)
+	if err != nil && err != io.EOF && context.Cause(b.ctx) == errStalled {
+		err = errStalled
+	}
+	return n, err
+}
+
+func (b *idleBody) Close() error {
+	b.watchdog.Stop()
+	err := b.body.Close()
+	b.cancel(nil)
+	return err
+}
@@ -0,0 +1,114 @@
+package manager
+
+import (
+	"fmt"
+
+	"github.com/joaomarcosfurtado/gnode/internal/alias"
+)
+
+// expandAlias follows user aliases until it reaches a spec that is not an
+// alias name. Cycles stop at the first repeated name.
+func (m *Manager) expandAlias(spec string) string {
+	seen := map[string]bool{}
+	for !seen[spec] {
+		target, ok := m.aliases.Get(spec)
+		if !ok {
+			return spec
+		}
+		seen[spec] = true
+		spec = target
+	}
+	return spec
+}
+
+func (m *Manager) SetAlias(name, spec string) error {
+	if err := alias.ValidateName(name); err != nil {
+		return err
+	}
+
+	resolved, err := m.resolveInstalled(spec)
+	if err != nil {
+		fmt.Printf("Warning: %s does not match any installed version yet\n", spec)
+	}
+
+	if err := m.aliases.Set(name, spec); err != nil {
+		return err
+	}
+
+	if resolved != "" {
+		fmt.Printf("%s -> %s (%s)\n", name, spec, resolved)
+	} else {
+		fmt.Printf("%s -> %s\n", name, spec)
+	}
+	return nil
+}
+
+func (m *Manager) RemoveAlias(name string) error {
+	if err := m.aliases.Remove(name); err != nil {
+		return err
+	}
+
+	fmt.Printf("Alias %s removed\n", name)
+	return nil
+}
+
+func (m *Manager) ShowAliases(name string) error {
+	if name != "" {
+		spec, ok := m.aliases.Get(name)
+		if !ok {
+			return fmt.Errorf("alias %s does not exist", name)
+		}
+		m.printAlias(alias.Alias{Name: name, Spec: spec})
+		return nil
+	}
+
+	aliases, err := m.aliases.List()
+	if err != nil {
+		return err
+	}
+
+	if len(aliases) == 0 {
+		fmt.Println("No aliases defined")
+		return nil
+	}
+
+	for _, a := range aliases {
+		m.printAlias(a)
+	}
+	return nil
+}
+
+func (m *Manager) printAlias(a alias.Alias) {
+	resolved, err := m.resolveInstalled(a.Spec)
+	if err != nil {
+		fmt.Printf("%s -> %s (not installed)\n", a.Name, a.Spec)
+		return
+	}
+	fmt.Printf("%s -> %s (%s)\n", a.Name, a.Spec, resolved)
+}
+
+// aliasesByVersion maps each installed version to the aliases that currently
+// resolve to it.
+func (m *Manager) aliasesByVersion() map[string][]string {
+	byVersion := map[string][]string{}
+
+	aliases, err := m.aliases.List()
+	if err != nil {
+		return byVersion
+	}
+
+	for _, a := range aliases {
+		if resolved, err := m.resolveInstalled(a.Spec); err == nil {
+			byVersion[resolved] = append(byVersion[resolved], a.Name)
+		}
+	}
+	return byVersion
+}
+
+func (m *Manager) defaultVersion() (string, error) {
+	spec, ok := m.aliases.Get(alias.Default)
+	if !ok {
+		return "", fmt.Errorf("no default alias set")
+	}
+	return m.resolveInstalled(spec)
+}
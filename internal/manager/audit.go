@@ -0,0 +1,111 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/joaomarcosfurtado/gnode/internal/version"
+)
+
+type auditResult struct {
+	Version          string   `json:"version"`
+	Line             string   `json:"line"`
+	Affected         bool     `json:"affected"`
+	FixedIn          string   `json:"fixed_in,omitempty"`
+	SecurityReleases []string `json:"security_releases"`
+}
+
+// Audit checks every installed release version against the security flags
+// in index.json and returns how many have a later security release in their
+// line.
+func (m *Manager) Audit(asJSON bool) (int, error) {
+	installed, err := m.getLocalVersions()
+	if err != nil {
+		return 0, err
+	}
+
+	index, err := m.version.ListRemote()
+	if err != nil {
+		return 0, err
+	}
+
+	results := []auditResult{}
+	affected := 0
+	for _, id := range installed {
+		if channel, _ := version.SplitChannel(id); channel != version.ChannelRelease {
+			continue
+		}
+		line, err := version.LineOf(id)
+		if err != nil {
+			continue
+		}
+
+		result := auditResult{Version: id, Line: line, SecurityReleases: []string{}}
+		for _, fix := range version.SecurityFixes(index, id) {
+			result.SecurityReleases = append(result.SecurityReleases, fix.Version)
+		}
+		if len(result.SecurityReleases) > 0 {
+			result.Affected = true
+			result.FixedIn = result.SecurityReleases[0]
+			affected++
+		}
+		results = append(results, result)
+	}
+
+	if asJSON {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return 0, fmt.Errorf("error encoding JSON: %v", err)
+		}
+		fmt.Println(string(data))
+		return affected, nil
+	}
+
+	if len(results) == 0 {
+		fmt.Println("No release versions installed")
+		return 0, nil
+	}
+
+	width := len("VERSION")
+	for _, r := range results {
+		if len(r.Version) > width {
+			width = len(r.Version)
+		}
+	}
+
+	fmt.Printf("  %-*s %-10s %s\n", width, "VERSION", "FIXED IN", "SECURITY RELEASES SINCE")
+	for _, r := range results {
+		if !r.Affected {
+			fmt.Printf("  %-*s %-10s %s\n", width, r.Version, "-", "none")
+			continue
+		}
+		fmt.Printf("  %-*s %-10s %s\n", width, r.Version, r.FixedIn, strings.Join(r.SecurityReleases, ", "))
+	}
+
+	if affected > 0 {
+		fmt.Printf("\n%d installed version(s) have known vulnerabilities; upgrade with 'gnode upgrade --all'\n", affected)
+	} else {
+		fmt.Println("\nNo installed version has a pending security release")
+	}
+	return affected, nil
+}
+
+// warnSecurity tells when a later security release exists in the line of
+// id. It only looks at the cached index so that switching stays offline.
+func (m *Manager) warnSecurity(id string) {
+	if channel, _ := version.SplitChannel(id); channel != version.ChannelRelease {
+		return
+	}
+	index, ok := m.version.CachedIndex()
+	if !ok {
+		return
+	}
+
+	fixes := version.SecurityFixes(index, id)
+	if len(fixes) == 0 {
+		return
+	}
+	line, _ := version.LineOf(id)
+	fmt.Printf("Warning: Node.js %s has known vulnerabilities fixed in %s; run 'gnode upgrade %s'\n", id, fixes[0].Version, strings.TrimPrefix(line, "v"))
+}
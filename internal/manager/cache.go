@@ -0,0 +1,129 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/joaomarcosfurtado/gnode/pkg/progress"
+)
+
+// CacheList prints the cached archives, most recently used first.
+func (m *Manager) CacheList() error {
+	entries, err := m.cache.List()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println("Archive cache is empty")
+		return nil
+	}
+
+	width := len("NAME")
+	for _, e := range entries {
+		if len(e.Name) > width {
+			width = len(e.Name)
+		}
+	}
+
+	var total int64
+	fmt.Printf("  %-*s %-12s %9s  %s\n", width, "NAME", "SHA256", "SIZE", "LAST USED")
+	for _, e := range entries {
+		total += e.Size
+		fmt.Printf("  %-*s %-12s %9s  %s\n", width, e.Name, e.SHA256[:12], progress.FormatBytes(e.Size), e.LastUsed.Format("2006-01-02 15:04"))
+	}
+	fmt.Printf("\n%d archive(s), %s in %s\n", len(entries), progress.FormatBytes(total), m.cache.Dir())
+	return nil
+}
+
+// CacheClean removes cached archives and unfinished downloads. With a
+// non-zero olderThan only those not used for that long are removed.
+func (m *Manager) CacheClean(olderThan time.Duration) error {
+	entries, err := m.cache.List()
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	removed, freed := 0, int64(0)
+	for _, e := range entries {
+		if olderThan > 0 && e.LastUsed.After(cutoff) {
+			continue
+		}
+		if err := m.cache.Remove(e); err != nil {
+			fmt.Printf("Warning: could not remove %s: %v\n", e.Path, err)
+			continue
+		}
+		removed++
+		freed += e.Size
+	}
+
+	partials, _ := os.ReadDir(m.config.PartialDir())
+	for _, p := range partials {
+		info, err := p.Info()
+		if err != nil || (olderThan > 0 && info.ModTime().After(cutoff)) {
+			continue
+		}
+		if os.Remove(filepath.Join(m.config.PartialDir(), p.Name())) == nil {
+			freed += info.Size()
+		}
+	}
+
+	fmt.Printf("Removed %d archive(s), freed %s\n", removed, progress.FormatBytes(freed))
+	return nil
+}
+
+// CacheVerify hashes every cached archive again, removes those that no
+// longer match and returns how many were corrupt.
+func (m *Manager) CacheVerify() (int, error) {
+	entries, err := m.cache.List()
+	if err != nil {
+		return 0, err
+	}
+	if len(entries) == 0 {
+		fmt.Println("Archive cache is empty")
+		return 0, nil
+	}
+
+	corrupt := 0
+	for _, e := range entries {
+		if err := m.cache.Verify(e); err != nil {
+			corrupt++
+			fmt.Printf("  FAIL %s: %v\n", e.Name, err)
+			if err := m.cache.Remove(e); err != nil {
+				fmt.Printf("Warning: could not remove %s: %v\n", e.Path, err)
+			}
+			continue
+		}
+		fmt.Printf("  ok   %s\n", e.Name)
+	}
+
+	if corrupt > 0 {
+		fmt.Printf("\n%d corrupt archive(s) removed; they are downloaded again when needed\n", corrupt)
+	} else {
+		fmt.Printf("\nAll %d archive(s) match their SHA-256\n", len(entries))
+	}
+	return corrupt, nil
+}
+
+// Prefetch downloads the archive of the version spec resolves to into the
+// cache without installing it, so it can be installed offline later.
+func (m *Manager) Prefetch(spec string) error {
+	if m.config.Offline {
+		return fmt.Errorf("offline mode: cannot prefetch %s", spec)
+	}
+
+	resolved, err := m.resolveRemote(spec)
+	if err != nil {
+		return err
+	}
+
+	svc, release := m.serviceForID(resolved)
+	archivePath, err := m.downloadArchive(svc, release)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Node.js %s cached at %s\n", resolved, archivePath)
+	return nil
+}
@@ -0,0 +1,130 @@
+package manager
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joaomarcosfurtado/gnode/internal/verify"
+	"github.com/joaomarcosfurtado/gnode/internal/version"
+)
+
+// checksums returns the SHASUMS256.txt listing of a release and, when
+// signature verification is on, checks it against the release keys. The
+// files are kept in the cache so that offline installs can verify too.
+// Offline without a cached copy there is nothing to check against and nil
+// is returned: archives only enter the cache after passing verification.
+func (m *Manager) checksums(svc *version.Service, release string) (verify.Checksums, error) {
+	data, err := m.releaseFile(svc, release, "SHASUMS256.txt")
+	if err != nil {
+		switch {
+		case m.config.Offline && m.config.VerifySignature:
+			return nil, fmt.Errorf("offline mode: SHASUMS256.txt of %s is not cached, which signature verification requires", release)
+		case m.config.Offline:
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot verify %s: %v", release, err)
+	}
+
+	if m.config.VerifySignature {
+		data, err = m.verifySignature(svc, release, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sums, err := verify.ParseChecksums(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SHASUMS256.txt for %s: %v", release, err)
+	}
+	return sums, nil
+}
+
+// verifySignature checks SHASUMS256.txt against its detached signature
+// (.sig) or, failing that, its clearsigned copy (.asc), and returns the
+// checksum listing that was signed.
+func (m *Manager) verifySignature(svc *version.Service, release string, sums []byte) ([]byte, error) {
+	keyring, err := verify.ReleaseKeyring(m.config.Keyring)
+	if err != nil {
+		return nil, err
+	}
+
+	if signature, err := m.releaseFile(svc, release, "SHASUMS256.txt.sig"); err == nil {
+		signer, err := keyring.VerifyDetached(sums, signature)
+		if err != nil {
+			return nil, fmt.Errorf("SHASUMS256.txt for %s: %v", release, err)
+		}
+		fmt.Printf("SHASUMS256.txt signed by %s\n", signer)
+		return sums, nil
+	}
+
+	message, err := m.releaseFile(svc, release, "SHASUMS256.txt.asc")
+	if err != nil {
+		return nil, fmt.Errorf("no signature found for SHASUMS256.txt of %s (neither .sig nor .asc), refusing to install unsigned files", release)
+	}
+	signed, signer, err := keyring.VerifyClearsigned(message)
+	if err != nil {
+		return nil, fmt.Errorf("SHASUMS256.txt.asc for %s: %v", release, err)
+	}
+	fmt.Printf("SHASUMS256.txt signed by %s\n", signer)
+	return signed, nil
+}
+
+// releaseFile returns one of the checksum files published next to a
+// release's archives. Downloads are cached; offline only the cache is read.
+func (m *Manager) releaseFile(svc *version.Service, release, name string) ([]byte, error) {
+	cached := filepath.Join(svc.ShasumsCacheDir(release), name)
+	if m.config.Offline {
+		return os.ReadFile(cached)
+	}
+
+	url := strings.TrimSuffix(svc.GetShasumsURL(release), "SHASUMS256.txt") + name
+	data, err := m.downloadBytes(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cached), 0755); err == nil {
+		err = os.WriteFile(cached, data, 0644)
+	}
+	if err != nil {
+		fmt.Printf("Warning: could not cache %s: %v\n", name, err)
+	}
+	return data, nil
+}
+
+func (m *Manager) downloadBytes(url string) ([]byte, error) {
+	reader, err := m.downloader.Download(url)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", url, err)
+	}
+	return data, nil
+}
+
+// checksumName returns the name SHASUMS256.txt lists a release file under:
+// its path below the release directory, such as
+// "node-v20.12.0-linux-x64.tar.gz" or "win-x64/node.exe".
+func checksumName(url, release string) string {
+	marker := "/" + release + "/"
+	if i := strings.LastIndex(url, marker); i >= 0 {
+		return url[i+len(marker):]
+	}
+	return url[strings.LastIndex(url, "/")+1:]
+}
+
+// verifier returns the check for one release file, or nil without
+// checksums.
+func verifier(sums verify.Checksums, url, release string) func(sum string) error {
+	if sums == nil {
+		return nil
+	}
+	return sums.Verifier(checksumName(url, release))
+}
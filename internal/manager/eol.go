@@ -0,0 +1,123 @@
+package manager
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/joaomarcosfurtado/gnode/internal/version"
+)
+
+// schedule returns the release schedule: the copy embedded in gnode, with
+// the lines of a downloaded schedule.json taking precedence.
+func (m *Manager) schedule() version.Schedule {
+	if m.releaseSchedule != nil {
+		return m.releaseSchedule
+	}
+
+	m.releaseSchedule = version.DefaultSchedule()
+	if data, err := os.ReadFile(m.config.ScheduleCacheFile()); err == nil {
+		if cached, err := version.ParseSchedule(data); err == nil {
+			m.releaseSchedule = m.releaseSchedule.Merge(cached)
+		}
+	}
+	return m.releaseSchedule
+}
+
+// RefreshSchedule downloads schedule.json and caches it.
+func (m *Manager) RefreshSchedule() error {
+	reader, err := m.downloader.Download(m.config.ScheduleURL)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("error reading schedule: %v", err)
+	}
+	fetched, err := version.ParseSchedule(data)
+	if err != nil {
+		return fmt.Errorf("invalid schedule from %s: %v", m.config.ScheduleURL, err)
+	}
+
+	path := m.config.ScheduleCacheFile()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating cache directory: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+
+	m.releaseSchedule = version.DefaultSchedule().Merge(fetched)
+	fmt.Printf("Release schedule updated (%d lines)\n", len(fetched))
+	return nil
+}
+
+// supportNote returns the list marker for versions that are out of active
+// support, or "" for supported and unknown lines.
+func (m *Manager) supportNote(id string) string {
+	switch status := m.schedule().SupportStatus(id, time.Now()); status {
+	case version.SupportEOL, version.SupportMaintenance:
+		return status
+	}
+	return ""
+}
+
+func (m *Manager) warnEOL(id string) {
+	line, entry, ok := m.schedule().Lookup(id)
+	if !ok || entry.Status(time.Now()) != version.SupportEOL {
+		return
+	}
+	fmt.Printf("Warning: Node.js %s reached end-of-life on %s and no longer receives security fixes\n", line, entry.End)
+}
+
+// EOL prints the support phase of every installed version.
+func (m *Manager) EOL(update bool) error {
+	if update {
+		if err := m.RefreshSchedule(); err != nil {
+			return err
+		}
+	}
+
+	versions, err := m.getLocalVersions()
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		fmt.Println("No version installed")
+		return nil
+	}
+
+	width := len("VERSION")
+	for _, v := range versions {
+		if len(v) > width {
+			width = len(v)
+		}
+	}
+
+	now := time.Now()
+	eol := 0
+	fmt.Printf("  %-*s %-6s %-12s %s\n", width, "VERSION", "LINE", "STATUS", "END OF LIFE")
+	for _, v := range versions {
+		line, entry, ok := m.schedule().Lookup(v)
+		status, end := version.SupportUnknown, "-"
+		if ok {
+			status, end = entry.Status(now), entry.End
+		}
+		if line == "" {
+			line = "-"
+		}
+		if status == version.SupportEOL {
+			eol++
+		}
+		fmt.Printf("  %-*s %-6s %-12s %s\n", width, v, line, status, end)
+	}
+
+	if eol > 0 {
+		fmt.Printf("\n%d installed version(s) are end-of-life\n", eol)
+	}
+	return nil
+}
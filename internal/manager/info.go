@@ -0,0 +1,104 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/joaomarcosfurtado/gnode/internal/version"
+)
+
+type versionInfo struct {
+	version.NodeVersion
+	Channel   string `json:"channel"`
+	Installed bool   `json:"installed"`
+	Current   bool   `json:"current"`
+}
+
+func (m *Manager) Info(spec string, installedOnly, asJSON bool) error {
+	var resolved string
+	var err error
+	if installedOnly {
+		resolved, err = m.resolveInstalled(spec)
+	} else {
+		resolved, err = m.resolveRemote(spec)
+	}
+	if err != nil {
+		return err
+	}
+
+	svc, release := m.serviceForID(resolved)
+	record, err := svc.Find(release)
+	if err != nil {
+		return err
+	}
+
+	info := versionInfo{NodeVersion: *record, Channel: svc.Channel()}
+	if _, err := os.Stat(m.config.GetVersionDir(resolved)); err == nil {
+		info.Installed = true
+	}
+	if current, err := m.getCurrentVersion(); err == nil && current == resolved {
+		info.Current = true
+	}
+
+	if asJSON {
+		data, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error encoding JSON: %v", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	printVersionInfo(info)
+	return nil
+}
+
+func printVersionInfo(info versionInfo) {
+	lts := "no"
+	if info.LTS.IsLTS() {
+		lts = string(info.LTS)
+	}
+
+	status := "not installed"
+	if info.Current {
+		status = "installed (current)"
+	} else if info.Installed {
+		status = "installed"
+	}
+
+	rows := []struct {
+		label string
+		value string
+	}{
+		{"Version", info.Version},
+		{"Channel", info.Channel},
+		{"Released", info.Date},
+		{"LTS", lts},
+		{"Security", yesNo(info.Security)},
+		{"npm", info.Npm},
+		{"V8", info.V8},
+		{"libuv", info.UV},
+		{"zlib", info.Zlib},
+		{"OpenSSL", info.OpenSSL},
+		{"ABI", info.Modules},
+		{"Status", status},
+		{"Files", strings.Join(info.Files, ", ")},
+	}
+
+	for _, row := range rows {
+		value := row.value
+		if value == "" {
+			value = "-"
+		}
+		fmt.Printf("%-10s %s\n", row.label+":", value)
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
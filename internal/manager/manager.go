@@ -2,19 +2,25 @@ package manager
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
-	"sort"
 	"strings"
 
+	"github.com/joaomarcosfurtado/gnode/internal/alias"
+	"github.com/joaomarcosfurtado/gnode/internal/auth"
+	"github.com/joaomarcosfurtado/gnode/internal/cache"
 	"github.com/joaomarcosfurtado/gnode/internal/downloader"
 	"github.com/joaomarcosfurtado/gnode/internal/extractor"
+	"github.com/joaomarcosfurtado/gnode/internal/httpclient"
+	"github.com/joaomarcosfurtado/gnode/internal/project"
 	"github.com/joaomarcosfurtado/gnode/internal/version"
 	"github.com/joaomarcosfurtado/gnode/pkg/config"
+	"github.com/joaomarcosfurtado/gnode/pkg/progress"
 )
 
 type Manager struct {
@@ -22,19 +28,286 @@ type Manager struct {
 	downloader *downloader.Downloader
 	extractor  *extractor.Extractor
 	version    *version.Service
+	channels   map[string]*version.Service
+	aliases    *alias.Store
+	cache      *cache.Store
+	client     *httpclient.Client
+
+	releaseSchedule version.Schedule
 }
 
-func NewManager(cfg *config.Config) (*Manager, error) {
-	return &Manager{
+// NewManager creates a manager whose network requests are bound to ctx.
+// Cancelling ctx aborts downloads and rolls back an install in progress.
+func NewManager(ctx context.Context, cfg *config.Config) (*Manager, error) {
+	creds := auth.Credentials{
+		Username: cfg.MirrorUsername,
+		Password: cfg.MirrorPassword,
+		Token:    cfg.MirrorToken,
+	}
+	mirrorAuth, err := auth.New(cfg.CustomMirrors(), creds, cfg.NetrcFile)
+	if err != nil {
+		return nil, fmt.Errorf("error loading mirror credentials: %v", err)
+	}
+
+	client, err := httpclient.New(ctx, httpclient.Options{
+		Auth:       mirrorAuth,
+		CACert:     cfg.CACert,
+		ClientCert: cfg.ClientCert,
+		ClientKey:  cfg.ClientKey,
+		Proxy:      cfg.Proxy,
+		Insecure:   cfg.Insecure,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error configuring HTTPS: %v", err)
+	}
+	ext := extractor.NewExtractor()
+	ext.SetContext(ctx)
+
+	m := &Manager{
 		config:     cfg,
-		downloader: downloader.NewDownloader(),
-		extractor:  extractor.NewExtractor(),
-		version:    version.NewService(cfg.GetDistURL()),
-	}, nil
+		downloader: newDownloader(cfg, client),
+		extractor:  ext,
+		channels:   map[string]*version.Service{},
+		aliases:    alias.NewStore(cfg.AliasDir()),
+		cache:      cache.NewStore(cfg.ArchiveCacheDir()),
+		client:     client,
+	}
+	m.version = m.newVersionService(version.ChannelRelease, version.ChannelRelease)
+	return m, nil
+}
+
+// SetProgress subscribes r to the progress of downloads and extractions.
+func (m *Manager) SetProgress(r progress.Reporter) {
+	m.downloader.SetProgress(r)
+	m.extractor.SetProgress(r)
+}
+
+// newVersionService creates the service for a mirror channel (a release
+// channel or unofficial builds) serving versions of the given channel.
+func (m *Manager) newVersionService(mirror, channel string) *version.Service {
+	svc := version.NewService(m.config.MirrorURLs(mirror), m.config.IndexCacheDir(mirror), m.config.IndexTTL)
+	svc.SetChannel(channel)
+	svc.SetRefresh(m.config.RefreshIndex)
+	svc.SetOffline(m.config.Offline)
+	svc.SetClient(m.client)
+	return svc
+}
+
+// serviceFor returns the version service of a release channel. Pre-release
+// channels are created on first use.
+func (m *Manager) serviceFor(channel string) *version.Service {
+	if channel == version.ChannelRelease {
+		return m.version
+	}
+	if svc, ok := m.channels[channel]; ok {
+		return svc
+	}
+	svc := m.newVersionService(channel, channel)
+	m.channels[channel] = svc
+	return svc
+}
+
+func newDownloader(cfg *config.Config, client *httpclient.Client) *downloader.Downloader {
+	d := downloader.NewDownloader()
+	d.SetOffline(cfg.Offline)
+	d.SetClient(client)
+	d.SetPartialDir(cfg.PartialDir())
+	d.SetConnections(cfg.Connections)
+
+	var groups [][]string
+	for _, channel := range config.MirrorChannels {
+		groups = append(groups, cfg.MirrorURLs(channel))
+	}
+	d.SetMirrors(groups)
+	return d
+}
+
+// serviceForID returns the service an installed or resolved version name
+// belongs to, together with the bare version used in download URLs.
+func (m *Manager) serviceForID(id string) (*version.Service, string) {
+	channel, v := version.SplitChannel(id)
+	bare, _ := version.SplitFlavor(v)
+	return m.serviceFor(channel), bare
+}
+
+func (m *Manager) unofficialService() *version.Service {
+	if svc, ok := m.channels[config.MIRROR_UNOFFICIAL]; ok {
+		return svc
+	}
+	svc := m.newVersionService(config.MIRROR_UNOFFICIAL, version.ChannelRelease)
+	m.channels[config.MIRROR_UNOFFICIAL] = svc
+	return svc
+}
+
+// buildSource picks where the archive for a release comes from. Official
+// builds are preferred; musl and architectures nodejs.org does not publish
+// (armv6l, riscv64, loong64...) fall back to unofficial builds.
+func (m *Manager) buildSource(svc *version.Service, release string) (*version.Service, error) {
+	goos, arch, flavor := m.config.GOOS, m.config.NodeArch(), m.config.Flavor()
+
+	if flavor == "" {
+		record, err := svc.Find(release)
+		if err != nil || record.HasBuild(goos, arch, "") {
+			return svc, nil
+		}
+	}
+
+	if svc.Channel() != version.ChannelRelease {
+		return nil, fmt.Errorf("no %s build of %s in the %s channel", platformName(goos, arch, flavor), release, svc.Channel())
+	}
+
+	unofficial := m.unofficialService()
+	record, err := unofficial.Find(release)
+	if err != nil {
+		return nil, fmt.Errorf("no official %s build of %s, and unofficial builds are unavailable: %v", platformName(goos, arch, flavor), release, err)
+	}
+	if !record.HasBuild(goos, arch, flavor) {
+		return nil, fmt.Errorf("no official or unofficial %s build of %s", platformName(goos, arch, flavor), release)
+	}
+
+	fmt.Printf("No official %s build, using unofficial builds\n", platformName(goos, arch, flavor))
+	return unofficial, nil
+}
+
+func platformName(goos, arch, flavor string) string {
+	name := goos + "-" + arch
+	if flavor != "" {
+		name += "-" + flavor
+	}
+	return name
+}
+
+// resolveRemote turns a spec into an installable version. Channel-qualified
+// specs such as "rc/22" resolve to channel-qualified names ("rc/v22.0.0-rc.1").
+func (m *Manager) resolveRemote(spec string) (string, error) {
+	channel, rest := version.SplitChannel(m.expandAlias(spec))
+	svc := m.serviceFor(channel)
+
+	flavor := m.config.Flavor()
+	if version.IsExactVersion(rest) {
+		bare, _ := version.SplitFlavor(svc.NormalizeVersion(rest))
+		return version.JoinChannel(channel, version.JoinFlavor(bare, flavor)), nil
+	}
+
+	resolved, err := svc.ResolveRemote(rest)
+	if err != nil {
+		return "", err
+	}
+	return version.JoinChannel(channel, version.JoinFlavor(resolved, flavor)), nil
+}
+
+func (m *Manager) resolveInstalled(spec string) (string, error) {
+	channel, rest := version.SplitChannel(m.expandAlias(spec))
+
+	installed, err := m.getLocalVersions()
+	if err != nil {
+		return "", err
+	}
+
+	// Only builds for this system's libc are candidates: a glibc build does
+	// not run on Alpine and vice versa.
+	flavor := m.config.Flavor()
+	var candidates []string
+	for _, id := range installed {
+		c, v := version.SplitChannel(id)
+		bare, f := version.SplitFlavor(v)
+		if c == channel && f == flavor {
+			candidates = append(candidates, bare)
+		}
+	}
+
+	rest, _ = version.SplitFlavor(rest)
+	resolved, err := m.serviceFor(channel).ResolveLocal(rest, candidates)
+	if err != nil {
+		return "", err
+	}
+	return version.JoinChannel(channel, version.JoinFlavor(resolved, flavor)), nil
+}
+
+func (m *Manager) Resolve(spec string, installedOnly bool) error {
+	var resolved string
+	var err error
+	if installedOnly {
+		resolved, err = m.resolveInstalled(spec)
+	} else {
+		resolved, err = m.resolveRemote(spec)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(resolved)
+	return nil
+}
+
+func (m *Manager) projectSpec() (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("error getting working directory: %v", err)
+	}
+
+	file, fileErr := project.FindVersionFile(wd)
+	if fileErr == nil {
+		fmt.Printf("Found '%s' with version <%s>\n", file.Path, file.Spec)
+		return file.Spec, nil
+	}
+
+	pkg, err := project.FindPackageJSON(wd)
+	if err != nil {
+		return "", fileErr
+	}
+
+	spec, field := pkg.Spec()
+	if spec == "" {
+		return "", fmt.Errorf("%v, and %s declares no node version", fileErr, pkg.Path)
+	}
+
+	fmt.Printf("Found %s in '%s' with version <%s>\n", field, pkg.Path, spec)
+	return spec, nil
+}
+
+func (m *Manager) warnEngineMismatch(resolved string) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return
+	}
+
+	pkg, err := project.FindPackageJSON(wd)
+	if err != nil {
+		return
+	}
+
+	_, bare := version.SplitChannel(resolved)
+	v, err := version.ParseSemver(bare)
+	if err != nil {
+		return
+	}
+
+	for _, c := range pkg.Constraints() {
+		r, err := version.ParseRange(c.Range)
+		if err != nil {
+			fmt.Printf("Warning: could not parse %s %q in %s: %v\n", c.Field, c.Range, pkg.Path, err)
+			continue
+		}
+		if !r.Match(v) {
+			fmt.Printf("Warning: Node.js %s does not satisfy %s %q declared in %s\n", resolved, c.Field, c.Range, pkg.Path)
+		}
+	}
 }
 
 func (m *Manager) Install(versionStr string) error {
-	version := m.version.NormalizeVersion(versionStr)
+	if versionStr == "" {
+		spec, err := m.projectSpec()
+		if err != nil {
+			return err
+		}
+		versionStr = spec
+	}
+
+	version, err := m.resolveRemote(versionStr)
+	if err != nil {
+		return err
+	}
 	fmt.Printf("Installing Node.js %s...\n", version)
 
 	versionDir := m.config.GetVersionDir(version)
@@ -47,20 +320,41 @@ func (m *Manager) Install(versionStr string) error {
 		return fmt.Errorf("error creating version directory: %v", err)
 	}
 
+	if err := m.installTo(version, versionDir); err != nil {
+		os.RemoveAll(versionDir)
+		if m.client.Context().Err() != nil {
+			return fmt.Errorf("installation of Node.js %s cancelled", version)
+		}
+		return err
+	}
+	return nil
+}
+
+// installTo downloads and unpacks version into versionDir. The caller
+// removes versionDir when it fails.
+func (m *Manager) installTo(version, versionDir string) error {
+	svc, release := m.serviceForID(version)
+
 	if runtime.GOOS == "windows" {
-		return m.installWindows(version, versionDir)
+		return m.installWindows(svc, release, versionDir)
 	}
 
-	downloadURL := m.version.GetDownloadURL(version, m.config.GOOS, m.config.GOARCH)
-	reader, err := m.downloader.Download(downloadURL)
+	archivePath, err := m.downloadArchive(svc, release)
 	if err != nil {
-		os.RemoveAll(versionDir)
 		return err
 	}
-	defer reader.Close()
 
-	if err := m.extractor.ExtractTarGz(reader, versionDir); err != nil {
-		os.RemoveAll(versionDir)
+	archive, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("error opening archive: %v", err)
+	}
+	defer archive.Close()
+
+	extract := m.extractor.ExtractTarGz
+	if strings.HasSuffix(archivePath, ".tar.xz") {
+		extract = m.extractor.ExtractTarXz
+	}
+	if err := extract(archive, versionDir); err != nil {
 		return err
 	}
 
@@ -68,62 +362,53 @@ func (m *Manager) Install(versionStr string) error {
 	return nil
 }
 
-func (m *Manager) installWindows(version, versionDir string) error {
+func (m *Manager) installWindows(svc *version.Service, version, versionDir string) error {
+	if m.config.Offline {
+		return m.installWindowsZip(svc, version, versionDir)
+	}
+
 	fmt.Printf("Checking available download options...\n")
 
-	strategy := m.version.GetDownloadStrategy(version, m.config.GOARCH)
+	strategy := svc.GetDownloadStrategy(version, m.config.GOARCH)
 
 	switch strategy {
 	case "zip":
 		fmt.Printf("Using ZIP distribution...\n")
-		return m.installWindowsZip(version, versionDir)
+		return m.installWindowsZip(svc, version, versionDir)
 
 	case "binaries":
 		fmt.Printf("Using individual binaries...\n")
-		return m.installWindowsBinaries(version, versionDir)
+		return m.installWindowsBinaries(svc, version, versionDir)
 
 	default:
 		return fmt.Errorf("no compatible download found for Node.js %s", version)
 	}
 }
 
-func (m *Manager) installWindowsZip(version, versionDir string) error {
-	downloadURL := m.version.GetDownloadURL(version, m.config.GOOS, m.config.GOARCH)
-	reader, err := m.downloader.Download(downloadURL)
+func (m *Manager) installWindowsZip(svc *version.Service, version, versionDir string) error {
+	archivePath, err := m.downloadArchive(svc, version)
 	if err != nil {
 		return fmt.Errorf("error downloading ZIP: %v", err)
 	}
-	defer reader.Close()
-
-	tempZip := filepath.Join(versionDir, "temp.zip")
-	tempFile, err := os.Create(tempZip)
-	if err != nil {
-		return fmt.Errorf("error creating temp file: %v", err)
-	}
-
-	_, err = io.Copy(tempFile, reader)
-	tempFile.Close()
-	if err != nil {
-		os.Remove(tempZip)
-		return fmt.Errorf("error saving ZIP: %v", err)
-	}
 
 	fmt.Printf("Extracting ZIP...\n")
-	if err := m.extractor.ExtractZip(tempZip, versionDir); err != nil {
-		os.Remove(tempZip)
+	if err := m.extractor.ExtractZip(archivePath, versionDir); err != nil {
 		return fmt.Errorf("error extracting ZIP: %v", err)
 	}
 
-	os.Remove(tempZip)
-
 	fmt.Printf("✓ Node.js %s installed successfully from ZIP\n", version)
 	return nil
 }
 
-func (m *Manager) installWindowsBinaries(version, versionDir string) error {
+func (m *Manager) installWindowsBinaries(svc *version.Service, version, versionDir string) error {
 	fmt.Printf("Downloading Node.js binaries...\n")
 
-	available := m.version.CheckAvailableFiles(version, m.config.GOARCH)
+	available := svc.CheckAvailableFiles(version, m.config.GOARCH)
+
+	sums, err := m.checksums(svc, version)
+	if err != nil {
+		return err
+	}
 
 	files := []struct {
 		key      string
@@ -131,11 +416,11 @@ func (m *Manager) installWindowsBinaries(version, versionDir string) error {
 		filename string
 		required bool
 	}{
-		{"node", m.version.GetWindowsNodeURL, "node.exe", true},
-		{"npm", m.version.GetWindowsNpmURL, "npm", false},
-		{"npm.cmd", m.version.GetWindowsNpmCmdURL, "npm.cmd", false},
-		{"npx", m.version.GetWindowsNpxURL, "npx", false},
-		{"npx.cmd", m.version.GetWindowsNpxCmdURL, "npx.cmd", false},
+		{"node", svc.GetWindowsNodeURL, "node.exe", true},
+		{"npm", svc.GetWindowsNpmURL, "npm", false},
+		{"npm.cmd", svc.GetWindowsNpmCmdURL, "npm.cmd", false},
+		{"npx", svc.GetWindowsNpxURL, "npx", false},
+		{"npx.cmd", svc.GetWindowsNpxCmdURL, "npx.cmd", false},
 	}
 
 	downloadedFiles := 0
@@ -152,8 +437,21 @@ func (m *Manager) installWindowsBinaries(version, versionDir string) error {
 		url := file.getURL(version, m.config.GOARCH)
 		fmt.Printf("Downloading %s...\n", file.filename)
 
-		reader, err := m.downloader.Download(url)
-		if err != nil {
+		filePath := filepath.Join(versionDir, file.filename)
+		check := verifier(sums, url, version)
+		if check != nil && !file.required {
+			if _, listed := sums[checksumName(url, version)]; !listed {
+				if m.config.VerifySignature {
+					fmt.Printf("Skipping %s (not listed in SHASUMS256.txt, cannot be verified)\n", file.filename)
+					available[file.key] = false
+					continue
+				}
+				fmt.Printf("Warning: %s is not listed in SHASUMS256.txt, skipping verification\n", file.filename)
+				check = nil
+			}
+		}
+
+		if err := m.downloader.DownloadFile(url, filePath, check); err != nil {
 			if file.required {
 				return fmt.Errorf("error downloading required file %s: %v", file.filename, err)
 			}
@@ -161,21 +459,6 @@ func (m *Manager) installWindowsBinaries(version, versionDir string) error {
 			continue
 		}
 
-		filePath := filepath.Join(versionDir, file.filename)
-		outFile, err := os.Create(filePath)
-		if err != nil {
-			reader.Close()
-			return fmt.Errorf("error creating file %s: %v", file.filename, err)
-		}
-
-		_, err = io.Copy(outFile, reader)
-		outFile.Close()
-		reader.Close()
-
-		if err != nil {
-			return fmt.Errorf("error writing file %s: %v", file.filename, err)
-		}
-
 		downloadedFiles++
 		fmt.Printf("✓ Downloaded %s\n", file.filename)
 	}
@@ -237,11 +520,17 @@ if exist "%NPX_CLI_JS%" (
 }
 
 func (m *Manager) Use(versionStr string, printEnv bool) error {
-	version := m.version.NormalizeVersion(versionStr)
-	versionDir := m.config.GetVersionDir(version)
+	if versionStr == "" {
+		spec, err := m.projectSpec()
+		if err != nil {
+			return err
+		}
+		versionStr = spec
+	}
 
-	if _, err := os.Stat(versionDir); os.IsNotExist(err) {
-		return fmt.Errorf("node.js %s is not installed. Execute 'gnode install %v' first", version, versionStr)
+	version, err := m.resolveInstalled(versionStr)
+	if err != nil {
+		return fmt.Errorf("node.js %s is not installed. Execute 'gnode install %v' first", versionStr, versionStr)
 	}
 
 	if err := m.ensureInSystemPath(); err != nil {
@@ -253,6 +542,9 @@ func (m *Manager) Use(versionStr string, printEnv bool) error {
 	}
 
 	fmt.Printf("Now using Node.js %s\n", version)
+	m.warnEOL(version)
+	m.warnSecurity(version)
+	m.warnEngineMismatch(version)
 
 	if m.needsPathRefresh() {
 		fmt.Printf("Please restart your terminal or run: refreshenv\n")
@@ -459,78 +751,268 @@ func (m *Manager) ListLocal() error {
 	}
 
 	current, _ := m.getCurrentVersion()
+	aliases := m.aliasesByVersion()
 	fmt.Println("Versions installed:")
 	for _, v := range versions {
 		marker := "  "
 		if v == current {
 			marker = "* "
 		}
-		fmt.Printf("%s%s\n", marker, v)
+		line := marker + v
+		if names, ok := aliases[v]; ok {
+			line += " (" + strings.Join(names, ", ") + ")"
+		}
+		if note := m.supportNote(v); note != "" {
+			line += " [" + note + "]"
+		}
+		fmt.Println(line)
 	}
 
 	return nil
 }
 
-func (m *Manager) ListRemote() error {
-	versions, err := m.version.ListRemote()
+type ListRemoteOptions struct {
+	Spec         string
+	Channel      string
+	LTS          bool
+	LTSCodename  string
+	Major        int
+	HasMajor     bool
+	Since        string
+	Until        string
+	SecurityOnly bool
+	All          bool
+	Installable  bool
+}
+
+func (m *Manager) ListRemote(opts ListRemoteOptions) error {
+	channel, spec := version.SplitChannel(m.expandAlias(opts.Spec))
+	if opts.Channel != "" {
+		if opts.Spec != "" && channel != version.ChannelRelease && channel != opts.Channel {
+			return fmt.Errorf("spec %s conflicts with --channel %s", opts.Spec, opts.Channel)
+		}
+		channel = opts.Channel
+	}
+	svc := m.serviceFor(channel)
+
+	var versions []version.NodeVersion
+	var err error
+	if spec == "" || spec == "*" {
+		versions, err = svc.ListRemote()
+	} else {
+		versions, err = svc.FilterRemote(spec)
+	}
 	if err != nil {
 		return err
 	}
 
-	limit := 20
-	if len(versions) > limit {
-		fmt.Printf("Available versions: (first %d):\n", limit)
-		for i := 0; i < limit; i++ {
-			fmt.Printf("%s\n", versions[i].Version)
+	versions = m.filterRemote(versions, opts)
+	if len(versions) == 0 {
+		fmt.Println("No versions match the given filters")
+		return nil
+	}
+
+	installed := map[string]bool{}
+	if local, err := m.getLocalVersions(); err == nil {
+		for _, id := range local {
+			c, v := version.SplitChannel(id)
+			bare, flavor := version.SplitFlavor(v)
+			if c == channel && flavor == m.config.Flavor() {
+				installed[bare] = true
+			}
 		}
-		fmt.Println("... and more")
+	}
+
+	shown := versions
+	limit := 20
+	if !opts.All && len(versions) > limit {
+		shown = versions[:limit]
+		fmt.Printf("Available versions: (first %d of %d):\n", limit, len(versions))
 	} else {
 		fmt.Printf("Available versions: (%d):\n", len(versions))
-		for _, v := range versions {
-			fmt.Printf("%s\n", v.Version)
+	}
+
+	width := len("VERSION")
+	for _, v := range shown {
+		if len(v.Version) > width {
+			width = len(v.Version)
 		}
 	}
 
+	fmt.Printf("  %-*s %-10s %-8s %-10s %s\n", width, "VERSION", "DATE", "NPM", "LTS", "NOTES")
+	for _, v := range shown {
+		printRemoteVersion(v, installed[v.Version], m.supportNote(v.Version), width)
+	}
+
+	if len(shown) < len(versions) {
+		fmt.Printf("... and %d more (use --all to show everything)\n", len(versions)-len(shown))
+	}
+
 	return nil
 }
 
-func (m *Manager) ShowWhich() error {
-	_, err := m.getCurrentVersion()
+func (m *Manager) filterRemote(versions []version.NodeVersion, opts ListRemoteOptions) []version.NodeVersion {
+	unofficial := map[string]bool{}
+	if opts.Installable {
+		unofficial = m.unofficialBuilds()
+	}
+
+	var filtered []version.NodeVersion
+	for _, v := range versions {
+		if opts.LTS && !v.LTS.IsLTS() {
+			continue
+		}
+		if opts.LTSCodename != "" && !strings.EqualFold(string(v.LTS), opts.LTSCodename) {
+			continue
+		}
+		if opts.HasMajor {
+			sv, err := version.ParseSemver(v.Version)
+			if err != nil || sv.Major != opts.Major {
+				continue
+			}
+		}
+		if opts.Since != "" && v.Date < opts.Since {
+			continue
+		}
+		if opts.Until != "" && v.Date > opts.Until {
+			continue
+		}
+		if opts.SecurityOnly && !v.Security {
+			continue
+		}
+		if opts.Installable && !m.hasOfficialBuild(v) && !unofficial[v.Version] {
+			continue
+		}
+		filtered = append(filtered, v)
+	}
+	return filtered
+}
+
+func (m *Manager) hasOfficialBuild(v version.NodeVersion) bool {
+	return m.config.Flavor() == "" && v.HasBuild(m.config.GOOS, m.config.NodeArch(), "")
+}
+
+// unofficialBuilds lists the releases that unofficial builds provide for
+// this platform. Errors only mean nothing extra is installable.
+func (m *Manager) unofficialBuilds() map[string]bool {
+	builds := map[string]bool{}
+	if m.config.GOOS != "linux" {
+		return builds
+	}
+
+	versions, err := m.unofficialService().ListRemote()
 	if err != nil {
-		return err
+		return builds
+	}
+	for _, v := range versions {
+		if v.HasBuild(m.config.GOOS, m.config.NodeArch(), m.config.Flavor()) {
+			builds[v.Version] = true
+		}
+	}
+	return builds
+}
+
+func printRemoteVersion(v version.NodeVersion, installed bool, support string, width int) {
+	marker := "  "
+	if installed {
+		marker = "* "
+	}
+
+	lts := "-"
+	if v.LTS.IsLTS() {
+		lts = string(v.LTS)
+	}
+
+	npm := v.Npm
+	if npm == "" {
+		npm = "-"
+	}
+
+	notes := ""
+	if installed {
+		notes = "installed"
+	}
+	if v.Security {
+		notes = strings.TrimSpace(notes + " security")
+	}
+	if support != "" {
+		notes = strings.TrimSpace(notes + " " + support)
+	}
+
+	line := fmt.Sprintf("%s%-*s %-10s %-8s %-10s %s", marker, width, v.Version, v.Date, npm, lts, notes)
+	fmt.Println(strings.TrimRight(line, " "))
+}
+
+func (m *Manager) ShowWhich(versionStr string) error {
+	baseDir := m.config.CurrentDir
+	if versionStr == "" {
+		if _, err := m.getCurrentVersion(); err != nil {
+			return err
+		}
+	} else {
+		version, err := m.resolveInstalled(versionStr)
+		if err != nil {
+			return fmt.Errorf("node.js %s is not installed", versionStr)
+		}
+		baseDir = m.config.GetVersionDir(version)
 	}
 
 	var nodePath string
 	if runtime.GOOS == "windows" {
-		nodePath = filepath.Join(m.config.CurrentDir, "node.exe")
+		nodePath = filepath.Join(baseDir, "node.exe")
 	} else {
-		nodePath = filepath.Join(m.config.CurrentDir, "bin", "node")
+		nodePath = filepath.Join(baseDir, "bin", "node")
 	}
 
 	fmt.Println(nodePath)
 	return nil
 }
 
-func (m *Manager) Uninstall(versionStr string) error {
-	version := m.version.NormalizeVersion(versionStr)
-	versionDir := m.config.GetVersionDir(version)
-
-	if _, err := os.Stat(versionDir); os.IsNotExist(err) {
-		return fmt.Errorf("node.js %s is not installed", version)
+func (m *Manager) Uninstall(versionStr string, pruneAliases bool) error {
+	version, err := m.resolveInstalled(versionStr)
+	if err != nil {
+		return fmt.Errorf("node.js %s is not installed", versionStr)
 	}
+	versionDir := m.config.GetVersionDir(version)
 
 	if current, err := m.getCurrentVersion(); err == nil && current == version {
 		return fmt.Errorf("it is not possible to uninstall the current version (%s). use other version first", version)
 	}
 
+	affected := m.aliasesByVersion()[version]
+
 	if err := os.RemoveAll(versionDir); err != nil {
 		return fmt.Errorf("error removing version %v", err)
 	}
 
 	fmt.Printf("Node.js %s uninstalled with success!\n", version)
+	m.handleDanglingAliases(affected, pruneAliases)
 	return nil
 }
 
+func (m *Manager) handleDanglingAliases(names []string, prune bool) {
+	for _, name := range names {
+		spec, ok := m.aliases.Get(name)
+		if !ok {
+			continue
+		}
+		if resolved, err := m.resolveInstalled(spec); err == nil {
+			fmt.Printf("Alias %s now points to %s\n", name, resolved)
+			continue
+		}
+
+		if !prune {
+			fmt.Printf("Warning: alias %s (%s) no longer matches any installed version. Run 'gnode unalias %s' to remove it\n", name, spec, name)
+			continue
+		}
+		if err := m.aliases.Remove(name); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+			continue
+		}
+		fmt.Printf("Removed dangling alias %s\n", name)
+	}
+}
+
 func (m *Manager) getLocalVersions() ([]string, error) {
 	entries, err := os.ReadDir(m.config.VersionsDir())
 	if err != nil {
@@ -542,15 +1024,39 @@ func (m *Manager) getLocalVersions() ([]string, error) {
 
 	var versions []string
 	for _, entry := range entries {
-		if entry.IsDir() {
+		if !entry.IsDir() {
+			continue
+		}
+		if !version.IsChannel(entry.Name()) {
 			versions = append(versions, entry.Name())
+			continue
+		}
+
+		channelEntries, err := os.ReadDir(filepath.Join(m.config.VersionsDir(), entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error reading directory versions: %v", err)
+		}
+		for _, ce := range channelEntries {
+			if ce.IsDir() {
+				versions = append(versions, version.JoinChannel(entry.Name(), ce.Name()))
+			}
 		}
 	}
 
-	sort.Strings(versions)
+	version.SortVersions(versions)
 	return versions, nil
 }
 
+// versionFromPath maps a directory under versions/ back to its version name,
+// keeping the channel prefix of pre-release installs.
+func (m *Manager) versionFromPath(target string) string {
+	rel, err := filepath.Rel(m.config.VersionsDir(), target)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return filepath.Base(target)
+	}
+	return filepath.ToSlash(rel)
+}
+
 func (m *Manager) getCurrentVersionWindows() (string, error) {
 	currentDir := m.config.CurrentDir
 
@@ -570,7 +1076,7 @@ func (m *Manager) getCurrentVersionWindows() (string, error) {
 	if err == nil {
 		target := strings.TrimSpace(string(output))
 		if target != "" && target != "null" && target != currentDir {
-			return filepath.Base(target), nil
+			return m.versionFromPath(target), nil
 		}
 	}
 
@@ -594,8 +1100,7 @@ func (m *Manager) getCurrentVersion() (string, error) {
 		return "", fmt.Errorf("no version of node.js is being used")
 	}
 
-	version := filepath.Base(target)
-	return version, nil
+	return m.versionFromPath(target), nil
 }
 
 func (m *Manager) Status() error {
@@ -676,12 +1181,23 @@ func (m *Manager) Init() error {
 		}
 	}
 
+	// Keep an existing current link; only a missing or dangling one is
+	// recreated, pointing at the default alias when there is one.
+	if _, err := os.Stat(currentPath); err == nil {
+		return nil
+	}
+
+	target := emptyPath
+	if def, err := m.defaultVersion(); err == nil {
+		target = m.config.GetVersionDir(def)
+	}
+
 	os.RemoveAll(currentPath)
 
 	if runtime.GOOS == "windows" {
-		err := createJunction(emptyPath, currentPath)
+		err := createJunction(target, currentPath)
 		if err != nil {
-			errCopy := copyDir(emptyPath, currentPath)
+			errCopy := copyDir(target, currentPath)
 			if errCopy != nil {
 				return fmt.Errorf("error creating junction or copying dir: %v, %v", err, errCopy)
 			}
@@ -690,7 +1206,7 @@ func (m *Manager) Init() error {
 		return nil
 	}
 
-	if err := os.Symlink(emptyPath, currentPath); err != nil {
+	if err := os.Symlink(target, currentPath); err != nil {
 		return fmt.Errorf("error creating symlink: %v", err)
 	}
 
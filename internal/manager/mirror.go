@@ -0,0 +1,157 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/joaomarcosfurtado/gnode/pkg/config"
+	"github.com/joaomarcosfurtado/gnode/pkg/progress"
+)
+
+const probeTimeout = 30 * time.Second
+
+type probeResult struct {
+	URL        string
+	Latency    time.Duration
+	Throughput float64 // bytes per second
+	Err        error
+}
+
+// ShowMirrors prints the mirrors of every channel in the order they are
+// tried.
+func (m *Manager) ShowMirrors() error {
+	for _, channel := range config.MirrorChannels {
+		fmt.Printf("%s:\n", channel)
+		for i, mirror := range m.config.MirrorURLs(channel) {
+			fmt.Printf("  %d. %s\n", i+1, mirror)
+		}
+	}
+	return nil
+}
+
+// ProbeMirrors measures latency and throughput of each mirror by fetching
+// its index.json, then records the fastest-first order unless save is
+// false. Without a channel every channel with configured mirrors is probed.
+func (m *Manager) ProbeMirrors(channel string, save bool) error {
+	if m.config.Offline {
+		return fmt.Errorf("offline mode: cannot probe mirrors")
+	}
+
+	channels := []string{channel}
+	if channel == "" {
+		channels = nil
+		for _, name := range config.MirrorChannels {
+			if len(m.config.Mirrors[name]) > 0 {
+				channels = append(channels, name)
+			}
+		}
+		if len(channels) == 0 {
+			fmt.Println("No mirrors configured, nothing to probe")
+			return nil
+		}
+	} else if !config.IsMirrorChannel(channel) {
+		return fmt.Errorf("unknown channel %q: expected one of %s", channel, strings.Join(config.MirrorChannels, ", "))
+	}
+
+	saved := false
+	for _, name := range channels {
+		mirrors := m.config.MirrorURLs(name)
+		fmt.Printf("Probing %d %s mirror(s)...\n", len(mirrors), name)
+
+		var results []probeResult
+		for _, mirror := range mirrors {
+			results = append(results, m.probeMirror(mirror))
+		}
+		sortProbeResults(results)
+
+		for i, r := range results {
+			if r.Err != nil {
+				fmt.Printf("  %d. %s  unreachable: %v\n", i+1, r.URL, r.Err)
+				continue
+			}
+			fmt.Printf("  %d. %s  %v  %s\n", i+1, r.URL, r.Latency.Round(time.Millisecond), progress.FormatRate(r.Throughput))
+		}
+
+		if !save || len(results) < 2 {
+			continue
+		}
+		var order []string
+		for _, r := range results {
+			order = append(order, r.URL)
+		}
+		if err := m.config.SaveMirrorOrder(name, order); err != nil {
+			return err
+		}
+		saved = true
+	}
+
+	if saved {
+		fmt.Printf("Preferred order saved to %s\n", m.config.MirrorOrderFile())
+	}
+	return nil
+}
+
+// probeMirror times the response headers of index.json as latency and the
+// body transfer as throughput.
+func (m *Manager) probeMirror(mirror string) probeResult {
+	result := probeResult{URL: mirror}
+	url := mirror + "/index.json"
+
+	ctx, cancel := context.WithTimeout(m.client.Context(), probeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	// Retries would distort the timings, so a failing mirror is reported
+	// as it is.
+	start := time.Now()
+	resp, err := m.client.DoOnce(req)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer resp.Body.Close()
+	result.Latency = time.Since(start)
+
+	if resp.StatusCode != http.StatusOK {
+		result.Err = fmt.Errorf("status %d", resp.StatusCode)
+		return result
+	}
+
+	bodyStart := time.Now()
+	n, err := io.Copy(io.Discard, resp.Body)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if elapsed := time.Since(bodyStart).Seconds(); elapsed > 0 {
+		result.Throughput = float64(n) / elapsed
+	}
+	return result
+}
+
+// sortProbeResults orders reachable mirrors by throughput, then latency.
+// Unreachable mirrors go last in their configured order.
+func sortProbeResults(results []probeResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if (a.Err == nil) != (b.Err == nil) {
+			return a.Err == nil
+		}
+		if a.Err != nil {
+			return false
+		}
+		if a.Throughput != b.Throughput {
+			return a.Throughput > b.Throughput
+		}
+		return a.Latency < b.Latency
+	})
+}
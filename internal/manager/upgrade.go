@@ -0,0 +1,376 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/joaomarcosfurtado/gnode/internal/version"
+)
+
+// releaseLine is one installed major version line, such as every v18.x.y
+// build for this platform.
+type releaseLine struct {
+	Major     int
+	Installed []string // installed versions, oldest first
+	Latest    *version.NodeVersion
+	Security  bool // a release newer than the installed ones is a security release
+}
+
+func (l releaseLine) newestInstalled() string {
+	return l.Installed[len(l.Installed)-1]
+}
+
+func (l releaseLine) bareNewest() string {
+	bare, _ := version.SplitFlavor(l.newestInstalled())
+	return bare
+}
+
+// upToDate reports whether the newest installed version is the newest
+// release of the line. Lines missing from the index count as up to date.
+func (l releaseLine) upToDate() bool {
+	return l.Latest == nil || l.Latest.Version == l.bareNewest()
+}
+
+type UpgradeOptions struct {
+	All               bool
+	ReinstallPackages bool
+	RemoveOld         bool
+}
+
+// releaseLines groups the installed release-channel versions for this
+// platform by major and finds the newest installable release of each.
+func (m *Manager) releaseLines() ([]releaseLine, error) {
+	installed, err := m.getLocalVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	flavor := m.config.Flavor()
+	byMajor := map[int]*releaseLine{}
+	var majors []int
+	for _, id := range installed {
+		channel, v := version.SplitChannel(id)
+		bare, f := version.SplitFlavor(v)
+		if channel != version.ChannelRelease || f != flavor {
+			continue
+		}
+		sv, err := version.ParseSemver(bare)
+		if err != nil || sv.Prerelease != "" {
+			continue
+		}
+		line, ok := byMajor[sv.Major]
+		if !ok {
+			line = &releaseLine{Major: sv.Major}
+			byMajor[sv.Major] = line
+			majors = append(majors, sv.Major)
+		}
+		line.Installed = append(line.Installed, id)
+	}
+	if len(majors) == 0 {
+		return nil, nil
+	}
+
+	remote, err := m.version.ListRemote()
+	if err != nil {
+		return nil, err
+	}
+	remote = m.filterRemote(remote, ListRemoteOptions{Installable: true})
+
+	var lines []releaseLine
+	for _, major := range majors {
+		line := byMajor[major]
+		newest, _ := version.ParseSemver(line.bareNewest())
+
+		for i := range remote {
+			sv, err := version.ParseSemver(remote[i].Version)
+			if err != nil || sv.Major != major || sv.Prerelease != "" {
+				continue
+			}
+			if line.Latest == nil {
+				line.Latest = &remote[i]
+			} else if latest, _ := version.ParseSemver(line.Latest.Version); sv.Compare(latest) > 0 {
+				line.Latest = &remote[i]
+			}
+			if remote[i].Security && sv.Compare(newest) > 0 {
+				line.Security = true
+			}
+		}
+		lines = append(lines, *line)
+	}
+	return lines, nil
+}
+
+// Outdated reports, for each installed major line, whether a newer minor or
+// patch release exists.
+func (m *Manager) Outdated() error {
+	lines, err := m.releaseLines()
+	if err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		fmt.Println("No release versions installed")
+		return nil
+	}
+
+	fmt.Printf("  %-6s %-10s %-10s %s\n", "LINE", "INSTALLED", "LATEST", "NOTES")
+	outdated := 0
+	for _, line := range lines {
+		latest := "-"
+		var notes []string
+		switch {
+		case line.Latest == nil:
+			notes = append(notes, "not in the remote index")
+		case line.upToDate():
+			latest = line.Latest.Version
+			notes = append(notes, "up to date")
+		default:
+			latest = line.Latest.Version
+			outdated++
+			notes = append(notes, updateKind(line.bareNewest(), latest))
+			if line.Security {
+				notes = append(notes, "security")
+			}
+		}
+		if line.Latest != nil && line.Latest.LTS.IsLTS() {
+			notes = append(notes, "lts/"+strings.ToLower(string(line.Latest.LTS)))
+		}
+
+		fmt.Printf("  %-6s %-10s %-10s %s\n", fmt.Sprintf("v%d", line.Major), line.newestInstalled(), latest, strings.Join(notes, ", "))
+	}
+
+	if outdated > 0 {
+		fmt.Printf("\n%d line(s) can be upgraded with 'gnode upgrade --all'\n", outdated)
+	}
+	return nil
+}
+
+func updateKind(from, to string) string {
+	a, errA := version.ParseSemver(from)
+	b, errB := version.ParseSemver(to)
+	if errA == nil && errB == nil && a.Minor != b.Minor {
+		return "minor"
+	}
+	return "patch"
+}
+
+// Upgrade installs the newest release of the line spec belongs to (the
+// line of the active version without a spec, or every line with All) and
+// moves exact aliases and the current link from the superseded versions
+// onto it.
+func (m *Manager) Upgrade(spec string, opts UpgradeOptions) error {
+	lines, err := m.releaseLines()
+	if err != nil {
+		return err
+	}
+
+	if !opts.All {
+		if spec == "" {
+			current, err := m.getCurrentVersion()
+			if err != nil {
+				return fmt.Errorf("no version in use: pass a version or --all")
+			}
+			spec = current
+		}
+
+		resolved, err := m.resolveInstalled(spec)
+		if err != nil {
+			return fmt.Errorf("node.js %s is not installed", spec)
+		}
+		channel, v := version.SplitChannel(resolved)
+		bare, _ := version.SplitFlavor(v)
+		sv, err := version.ParseSemver(bare)
+		if channel != version.ChannelRelease || err != nil || sv.Prerelease != "" {
+			return fmt.Errorf("%s is not a release version and cannot be upgraded", resolved)
+		}
+
+		var selected []releaseLine
+		for _, line := range lines {
+			if line.Major == sv.Major {
+				selected = append(selected, line)
+			}
+		}
+		lines = selected
+	}
+
+	if len(lines) == 0 {
+		fmt.Println("No release versions installed")
+		return nil
+	}
+
+	upgraded := 0
+	for _, line := range lines {
+		if line.upToDate() {
+			fmt.Printf("v%d is up to date (%s)\n", line.Major, line.newestInstalled())
+			continue
+		}
+		if err := m.upgradeLine(line, opts); err != nil {
+			return fmt.Errorf("error upgrading v%d: %v", line.Major, err)
+		}
+		upgraded++
+	}
+
+	if upgraded == 0 {
+		fmt.Println("Everything is up to date")
+	}
+	return nil
+}
+
+func (m *Manager) upgradeLine(line releaseLine, opts UpgradeOptions) error {
+	target := version.JoinFlavor(line.Latest.Version, m.config.Flavor())
+	fmt.Printf("Upgrading v%d: %s -> %s\n", line.Major, line.newestInstalled(), target)
+
+	if err := m.Install(target); err != nil {
+		return err
+	}
+
+	superseded := map[string]bool{}
+	for _, id := range line.Installed {
+		superseded[id] = true
+	}
+
+	if err := m.moveAliases(superseded, line.Latest.Version); err != nil {
+		return err
+	}
+
+	if current, err := m.getCurrentVersion(); err == nil && superseded[current] {
+		if err := m.updateCurrentVersion(target); err != nil {
+			return fmt.Errorf("error updating current version: %v", err)
+		}
+		fmt.Printf("Now using Node.js %s\n", target)
+	}
+
+	if opts.ReinstallPackages {
+		if err := m.reinstallGlobalPackages(line.newestInstalled(), target); err != nil {
+			fmt.Printf("Warning: could not reinstall global packages: %v\n", err)
+		}
+	}
+
+	if opts.RemoveOld {
+		for _, id := range line.Installed {
+			if err := m.Uninstall(id, false); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+		}
+	}
+	return nil
+}
+
+// moveAliases repoints aliases that pin one of the superseded versions
+// exactly. Aliases holding a range such as "18" or "lts/hydrogen" already
+// follow the newest installed version and are left alone.
+func (m *Manager) moveAliases(superseded map[string]bool, target string) error {
+	aliases, err := m.aliases.List()
+	if err != nil {
+		return err
+	}
+
+	for _, a := range aliases {
+		// Aliases of aliases follow the alias they point at.
+		if _, ok := m.aliases.Get(a.Spec); ok || !version.IsExactVersion(a.Spec) {
+			continue
+		}
+		resolved, err := m.resolveInstalled(a.Spec)
+		if err != nil || !superseded[resolved] {
+			continue
+		}
+		if err := m.aliases.Set(a.Name, target); err != nil {
+			return err
+		}
+		fmt.Printf("%s -> %s (was %s)\n", a.Name, target, a.Spec)
+	}
+	return nil
+}
+
+// globalPackages lists the packages installed with "npm install -g" in a
+// version, as name@version. npm and corepack ship with Node.js and linked
+// packages point into a working copy, so those are skipped.
+func (m *Manager) globalPackages(id string) ([]string, error) {
+	dir := filepath.Join(m.config.GetVersionDir(id), "lib", "node_modules")
+	if runtime.GOOS == "windows" {
+		dir = filepath.Join(m.config.GetVersionDir(id), "node_modules")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, "@") {
+			names = append(names, name)
+			continue
+		}
+		scoped, err := os.ReadDir(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		for _, s := range scoped {
+			names = append(names, name+"/"+s.Name())
+		}
+	}
+
+	var packages []string
+	for _, name := range names {
+		if name == "npm" || name == "corepack" || strings.HasPrefix(name, ".") {
+			continue
+		}
+		pkgDir := filepath.Join(dir, filepath.FromSlash(name))
+		if info, err := os.Lstat(pkgDir); err != nil || info.Mode()&os.ModeSymlink != 0 {
+			continue
+		}
+
+		var manifest struct {
+			Version string `json:"version"`
+		}
+		data, err := os.ReadFile(filepath.Join(pkgDir, "package.json"))
+		if err != nil || json.Unmarshal(data, &manifest) != nil || manifest.Version == "" {
+			packages = append(packages, name)
+			continue
+		}
+		packages = append(packages, name+"@"+manifest.Version)
+	}
+	return packages, nil
+}
+
+// reinstallGlobalPackages installs the global npm packages of one version
+// into another, using the npm of the target version.
+func (m *Manager) reinstallGlobalPackages(from, to string) error {
+	packages, err := m.globalPackages(from)
+	if err != nil {
+		return err
+	}
+	if len(packages) == 0 {
+		fmt.Printf("No global npm packages to carry over from %s\n", from)
+		return nil
+	}
+
+	binDir := filepath.Join(m.config.GetVersionDir(to), "bin")
+	npm := filepath.Join(binDir, "npm")
+	if runtime.GOOS == "windows" {
+		binDir = m.config.GetVersionDir(to)
+		npm = filepath.Join(binDir, "npm.cmd")
+	}
+	if _, err := os.Stat(npm); err != nil {
+		return fmt.Errorf("npm is not available in %s", to)
+	}
+
+	fmt.Printf("Reinstalling global packages: %s\n", strings.Join(packages, " "))
+	cmd := exec.Command(npm, append([]string{"install", "--global"}, packages...)...)
+	cmd.Env = append(os.Environ(), "PATH="+binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("npm install failed: %v", err)
+	}
+	return nil
+}
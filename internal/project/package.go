@@ -0,0 +1,128 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+type PackageJSON struct {
+	Path        string
+	EnginesNode string
+	VoltaNode   string
+	DevEngines  string
+}
+
+type packageFile struct {
+	Engines struct {
+		Node string `json:"node"`
+	} `json:"engines"`
+	Volta struct {
+		Node string `json:"node"`
+	} `json:"volta"`
+	DevEngines struct {
+		Runtime json.RawMessage `json:"runtime"`
+	} `json:"devEngines"`
+}
+
+type devEngine struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+func FindPackageJSON(startDir string) (*PackageJSON, error) {
+	dir, err := filepath.Abs(startDir)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving directory: %v", err)
+	}
+
+	for {
+		path := filepath.Join(dir, "package.json")
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return readPackageJSON(path)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, fmt.Errorf("no package.json found in %s or any parent directory", startDir)
+		}
+		dir = parent
+	}
+}
+
+func readPackageJSON(path string) (*PackageJSON, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+
+	var pkg packageFile
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("error decoding %s: %v", path, err)
+	}
+
+	return &PackageJSON{
+		Path:        path,
+		EnginesNode: pkg.Engines.Node,
+		VoltaNode:   pkg.Volta.Node,
+		DevEngines:  devEnginesNode(pkg.DevEngines.Runtime),
+	}, nil
+}
+
+// devEnginesNode extracts the node version from devEngines.runtime, which
+// may be a single object or a list of alternatives.
+func devEnginesNode(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var single devEngine
+	if err := json.Unmarshal(raw, &single); err == nil {
+		if single.Name == "node" {
+			return single.Version
+		}
+		return ""
+	}
+
+	var list []devEngine
+	if err := json.Unmarshal(raw, &list); err == nil {
+		for _, e := range list {
+			if e.Name == "node" {
+				return e.Version
+			}
+		}
+	}
+	return ""
+}
+
+// Spec returns the version the project asks for. A volta pin is the most
+// specific, followed by devEngines and then the engines range.
+func (p *PackageJSON) Spec() (string, string) {
+	switch {
+	case p.VoltaNode != "":
+		return p.VoltaNode, "volta.node"
+	case p.DevEngines != "":
+		return p.DevEngines, "devEngines.runtime"
+	case p.EnginesNode != "":
+		return p.EnginesNode, "engines.node"
+	}
+	return "", ""
+}
+
+type Constraint struct {
+	Field string
+	Range string
+}
+
+// Constraints returns the declared ranges an active version has to satisfy.
+func (p *PackageJSON) Constraints() []Constraint {
+	var constraints []Constraint
+	if p.EnginesNode != "" {
+		constraints = append(constraints, Constraint{Field: "engines.node", Range: p.EnginesNode})
+	}
+	if p.DevEngines != "" {
+		constraints = append(constraints, Constraint{Field: "devEngines.runtime", Range: p.DevEngines})
+	}
+	return constraints
+}
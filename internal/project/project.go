@@ -0,0 +1,88 @@
+package project
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var versionFiles = []string{".nvmrc", ".node-version", ".tool-versions"}
+
+type VersionFile struct {
+	Path string
+	Spec string
+}
+
+func FindVersionFile(startDir string) (*VersionFile, error) {
+	dir, err := filepath.Abs(startDir)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving directory: %v", err)
+	}
+
+	for {
+		for _, name := range versionFiles {
+			path := filepath.Join(dir, name)
+			info, err := os.Stat(path)
+			if err != nil || info.IsDir() {
+				continue
+			}
+
+			spec, err := readVersionFile(path)
+			if err != nil {
+				return nil, err
+			}
+			if spec == "" {
+				continue
+			}
+			return &VersionFile{Path: path, Spec: spec}, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, fmt.Errorf("no %s found in %s or any parent directory", strings.Join(versionFiles, ", "), startDir)
+		}
+		dir = parent
+	}
+}
+
+func readVersionFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %v", path, err)
+	}
+	defer file.Close()
+
+	toolVersions := filepath.Base(path) == ".tool-versions"
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := stripComment(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if !toolVersions {
+			return line, nil
+		}
+
+		// asdf format: "<tool> <version> [fallback versions...]"
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && (fields[0] == "nodejs" || fields[0] == "node") {
+			return fields[1], nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("error reading %s: %v", path, err)
+	}
+	return "", nil
+}
+
+func stripComment(line string) string {
+	if i := strings.Index(line, "#"); i >= 0 {
+		line = line[:i]
+	}
+	return strings.TrimSpace(line)
+}
@@ -0,0 +1,91 @@
+package verify
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Checksums maps file names, relative to a release directory, to their
+// SHA-256 as listed in SHASUMS256.txt.
+type Checksums map[string]string
+
+// ParseChecksums reads the "<sha256>  <file>" lines of SHASUMS256.txt.
+// sha256sum's binary marker ("<sha256> *<file>") is accepted too.
+func ParseChecksums(data []byte) (Checksums, error) {
+	sums := Checksums{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		hash, name := strings.ToLower(fields[0]), strings.TrimPrefix(fields[1], "*")
+		if len(hash) != sha256.Size*2 {
+			continue
+		}
+		if _, err := hex.DecodeString(hash); err != nil {
+			continue
+		}
+		sums[name] = hash
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(sums) == 0 {
+		return nil, fmt.Errorf("no checksums found")
+	}
+	return sums, nil
+}
+
+func FileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// File checks that the file at path has the checksum listed for name.
+func (c Checksums) File(path, name string) error {
+	if _, ok := c[name]; !ok {
+		return fmt.Errorf("no checksum for %s in SHASUMS256.txt", name)
+	}
+
+	actual, err := FileSHA256(path)
+	if err != nil {
+		return fmt.Errorf("error hashing %s: %v", name, err)
+	}
+	return c.Sum(name, actual)
+}
+
+// Sum checks that actual is the checksum listed for name.
+func (c Checksums) Sum(name, actual string) error {
+	expected, ok := c[name]
+	if !ok {
+		return fmt.Errorf("no checksum for %s in SHASUMS256.txt", name)
+	}
+	if actual != expected {
+		return fmt.Errorf("checksum mismatch for %s: expected sha256 %s, got %s", name, expected, actual)
+	}
+	return nil
+}
+
+// Verifier returns a check for Downloader.DownloadFile, which passes it the
+// SHA-256 computed during the download.
+func (c Checksums) Verifier(name string) func(sum string) error {
+	return func(sum string) error {
+		return c.Sum(name, sum)
+	}
+}
@@ -0,0 +1,96 @@
+package verify
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	sumHello = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	sumWorld = "486ea46224d1bb4fb680f34f7c9ad96a8f24ec88be73ea8e5a6c65260e9cb8a7"
+)
+
+func TestParseChecksums(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    Checksums
+		wantErr bool
+	}{
+		{
+			name: "release listing",
+			data: sumHello + "  node-v20.12.0-linux-x64.tar.gz\n" + sumWorld + "  win-x64/node.exe\n",
+			want: Checksums{"node-v20.12.0-linux-x64.tar.gz": sumHello, "win-x64/node.exe": sumWorld},
+		},
+		{
+			name: "binary marker and uppercase hash",
+			data: strings.ToUpper(sumHello) + " *node-v20.12.0.tar.gz\r\n",
+			want: Checksums{"node-v20.12.0.tar.gz": sumHello},
+		},
+		{
+			name: "malformed lines are skipped",
+			data: "-----BEGIN PGP SIGNED MESSAGE-----\nHash: SHA256\n\n" +
+				"abc123  short-hash.tar.gz\n" +
+				strings.Repeat("z", 64) + "  not-hex.tar.gz\n" +
+				sumHello + "  three fields\n" +
+				sumWorld + "  node.tar.xz\n",
+			want: Checksums{"node.tar.xz": sumWorld},
+		},
+		{name: "empty", data: "", wantErr: true},
+		{name: "nothing usable", data: "Hash: SHA256\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseChecksums([]byte(tt.data))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseChecksums = %v, want error", got)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseChecksums = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestChecksumsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node.tar.gz")
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sums := Checksums{"good.tar.gz": sumHello, "bad.tar.gz": sumWorld}
+
+	if err := sums.File(path, "good.tar.gz"); err != nil {
+		t.Errorf("matching file: %v", err)
+	}
+	if err := sums.File(path, "bad.tar.gz"); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("mismatching file: %v", err)
+	}
+	if err := sums.File(path, "missing.tar.gz"); err == nil || !strings.Contains(err.Error(), "no checksum") {
+		t.Errorf("unlisted file: %v", err)
+	}
+	if err := sums.File(filepath.Join(t.TempDir(), "missing"), "good.tar.gz"); err == nil {
+		t.Error("missing file should fail")
+	}
+}
+
+func TestChecksumsVerifier(t *testing.T) {
+	sums := Checksums{"node.tar.gz": sumHello}
+
+	if err := sums.Verifier("node.tar.gz")(sumHello); err != nil {
+		t.Errorf("matching sum: %v", err)
+	}
+	err := sums.Verifier("node.tar.gz")(sumWorld)
+	if err == nil || !strings.Contains(err.Error(), "expected sha256 "+sumHello+", got "+sumWorld) {
+		t.Errorf("mismatching sum: %v", err)
+	}
+	if err := sums.Verifier("other.tar.gz")(sumHello); err == nil || !strings.Contains(err.Error(), "no checksum") {
+		t.Errorf("unlisted file: %v", err)
+	}
+}
@@ -0,0 +1,21 @@
+#!/bin/sh
+# Regenerates nodejs-release-keys.asc from the keys of the active Node.js
+# releasers published in https://github.com/nodejs/release-keys.
+set -eu
+
+BASE="https://raw.githubusercontent.com/nodejs/release-keys/main"
+OUT="$(dirname "$0")/nodejs-release-keys.asc"
+TMP="$OUT.tmp"
+
+: > "$TMP"
+for fingerprint in $(curl -fsSL "$BASE/keys.list"); do
+	curl -fsSL "$BASE/keys/$fingerprint.asc" >> "$TMP"
+done
+
+if ! grep -q "BEGIN PGP PUBLIC KEY BLOCK" "$TMP"; then
+	rm -f "$TMP"
+	echo "no keys downloaded from $BASE" >&2
+	exit 1
+fi
+
+mv "$TMP" "$OUT"
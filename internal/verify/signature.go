@@ -0,0 +1,117 @@
+package verify
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/clearsign"
+)
+
+//go:generate sh keys/update.sh
+
+// releaseKeys holds the public keys of the Node.js releasers who sign
+// SHASUMS256.txt.
+//
+//go:embed keys/nodejs-release-keys.asc
+var releaseKeys []byte
+
+type Keyring struct {
+	entities openpgp.EntityList
+	source   string
+}
+
+// ReleaseKeyring returns the embedded Node.js release keys, or the keys in
+// path when it is set.
+func ReleaseKeyring(path string) (*Keyring, error) {
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading keyring: %v", err)
+		}
+		return parseKeyring(data, path)
+	}
+
+	if !bytes.Contains(releaseKeys, []byte("BEGIN PGP PUBLIC KEY BLOCK")) {
+		return nil, fmt.Errorf("this gnode build has no embedded release keys: set a keyring file or rebuild after 'go generate ./internal/verify'")
+	}
+	return parseKeyring(releaseKeys, "embedded release keys")
+}
+
+// parseKeyring reads armored keys, as published by the release team, or a
+// binary keyring exported with "gpg --export".
+func parseKeyring(data []byte, source string) (*Keyring, error) {
+	var entities openpgp.EntityList
+	var err error
+	if bytes.Contains(data, []byte("-----BEGIN PGP")) {
+		entities, err = readArmoredKeys(data)
+	} else {
+		entities, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid keyring %s: %v", source, err)
+	}
+	if len(entities) == 0 {
+		return nil, fmt.Errorf("keyring %s holds no keys", source)
+	}
+	return &Keyring{entities: entities, source: source}, nil
+}
+
+// readArmoredKeys reads concatenated armored key blocks, which
+// openpgp.ReadArmoredKeyRing stops reading after the first one.
+func readArmoredKeys(data []byte) (openpgp.EntityList, error) {
+	const begin = "-----BEGIN PGP PUBLIC KEY BLOCK-----"
+
+	var entities openpgp.EntityList
+	blocks := strings.Split(string(data), begin)
+	for _, block := range blocks[1:] {
+		keys, err := openpgp.ReadArmoredKeyRing(strings.NewReader(begin + block))
+		if err != nil {
+			return nil, err
+		}
+		entities = append(entities, keys...)
+	}
+	return entities, nil
+}
+
+// VerifyDetached checks a detached signature (SHASUMS256.txt.sig, binary or
+// armored) over data and returns the signer's fingerprint.
+func (k *Keyring) VerifyDetached(data, signature []byte) (string, error) {
+	var signer *openpgp.Entity
+	var err error
+	if bytes.Contains(signature, []byte("-----BEGIN PGP SIGNATURE")) {
+		signer, err = openpgp.CheckArmoredDetachedSignature(k.entities, bytes.NewReader(data), bytes.NewReader(signature), nil)
+	} else {
+		signer, err = openpgp.CheckDetachedSignature(k.entities, bytes.NewReader(data), bytes.NewReader(signature), nil)
+	}
+	if err != nil {
+		return "", fmt.Errorf("signature verification against %s failed: %v", k.source, err)
+	}
+	return fingerprint(signer), nil
+}
+
+// VerifyClearsigned checks a clearsigned message (SHASUMS256.txt.asc) and
+// returns the signed text with the signer's fingerprint.
+func (k *Keyring) VerifyClearsigned(message []byte) ([]byte, string, error) {
+	block, _ := clearsign.Decode(message)
+	if block == nil {
+		return nil, "", fmt.Errorf("not a clearsigned message")
+	}
+
+	signer, err := block.VerifySignature(k.entities, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("signature verification against %s failed: %v", k.source, err)
+	}
+	return block.Bytes, fingerprint(signer), nil
+}
+
+func fingerprint(e *openpgp.Entity) string {
+	if e == nil || e.PrimaryKey == nil {
+		return "unknown key"
+	}
+	return strings.ToUpper(hex.EncodeToString(e.PrimaryKey.Fingerprint))
+}
@@ -0,0 +1,176 @@
+package verify
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/clearsign"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+// TestEmbeddedReleaseKeys fails the test suite of a tree whose keys file was
+// never populated, which would make every signature check and every
+// hardened install fail at runtime.
+func TestEmbeddedReleaseKeys(t *testing.T) {
+	if !bytes.Contains(releaseKeys, []byte("BEGIN PGP PUBLIC KEY BLOCK")) {
+		t.Fatal("keys/nodejs-release-keys.asc holds no keys: run 'go generate ./internal/verify' and commit the result")
+	}
+
+	keyring, err := ReleaseKeyring("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keyring.entities) < 2 {
+		t.Errorf("only %d release key(s) embedded", len(keyring.entities))
+	}
+}
+
+const testShasums = sumHello + "  node-v20.12.0-linux-x64.tar.gz\n" + sumWorld + "  node-v20.12.0-linux-x64.tar.xz\n"
+
+var testKeyConfig = &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA}
+
+func newTestKey(t *testing.T, name string) *openpgp.Entity {
+	t.Helper()
+	e, err := openpgp.NewEntity(name, "", name+"@example.com", testKeyConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+// writeKeyring saves the public keys of signers as concatenated armored
+// blocks, the way keys/update.sh builds the embedded keyring, and loads it.
+func writeKeyring(t *testing.T, signers ...*openpgp.Entity) *Keyring {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, e := range signers {
+		w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := e.Serialize(w); err != nil {
+			t.Fatal(err)
+		}
+		w.Close()
+		buf.WriteString("\n")
+	}
+
+	path := filepath.Join(t.TempDir(), "keys.asc")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	keyring, err := ReleaseKeyring(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keyring
+}
+
+func detachSign(t *testing.T, signer *openpgp.Entity, data string, armored bool) []byte {
+	t.Helper()
+	var sig bytes.Buffer
+	sign := openpgp.DetachSign
+	if armored {
+		sign = openpgp.ArmoredDetachSign
+	}
+	if err := sign(&sig, signer, strings.NewReader(data), testKeyConfig); err != nil {
+		t.Fatal(err)
+	}
+	return sig.Bytes()
+}
+
+func clearSign(t *testing.T, signer *openpgp.Entity, data string) []byte {
+	t.Helper()
+	var msg bytes.Buffer
+	w, err := clearsign.Encode(&msg, signer.PrivateKey, testKeyConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte(data))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return msg.Bytes()
+}
+
+func TestParseKeyring(t *testing.T) {
+	first, second := newTestKey(t, "first"), newTestKey(t, "second")
+	if got := len(writeKeyring(t, first, second).entities); got != 2 {
+		t.Errorf("armored keyring holds %d keys, want 2", got)
+	}
+
+	var binary bytes.Buffer
+	first.Serialize(&binary)
+	second.Serialize(&binary)
+	keyring, err := parseKeyring(binary.Bytes(), "keys.gpg")
+	if err != nil || len(keyring.entities) != 2 {
+		t.Errorf("binary keyring = %v, %v, want 2 keys", keyring, err)
+	}
+
+	if _, err := parseKeyring([]byte("not a key"), "junk"); err == nil {
+		t.Error("junk keyring should fail")
+	}
+	if _, err := ReleaseKeyring(filepath.Join(t.TempDir(), "missing.asc")); err == nil {
+		t.Error("missing keyring should fail")
+	}
+}
+
+func TestVerifyDetached(t *testing.T) {
+	releaser, stranger := newTestKey(t, "releaser"), newTestKey(t, "stranger")
+	keyring := writeKeyring(t, newTestKey(t, "other releaser"), releaser)
+
+	for _, armored := range []bool{false, true} {
+		sig := detachSign(t, releaser, testShasums, armored)
+		signer, err := keyring.VerifyDetached([]byte(testShasums), sig)
+		if err != nil {
+			t.Fatalf("armored=%v: %v", armored, err)
+		}
+		if signer != fingerprint(releaser) {
+			t.Errorf("armored=%v: signer = %s, want %s", armored, signer, fingerprint(releaser))
+		}
+
+		tampered := strings.Replace(testShasums, sumHello, sumWorld, 1)
+		if _, err := keyring.VerifyDetached([]byte(tampered), sig); err == nil {
+			t.Errorf("armored=%v: tampered SHASUMS256.txt accepted", armored)
+		}
+	}
+
+	foreign := detachSign(t, stranger, testShasums, false)
+	if _, err := keyring.VerifyDetached([]byte(testShasums), foreign); err == nil {
+		t.Error("signature by a key outside the keyring accepted")
+	}
+}
+
+func TestVerifyClearsigned(t *testing.T) {
+	releaser, stranger := newTestKey(t, "releaser"), newTestKey(t, "stranger")
+	keyring := writeKeyring(t, releaser)
+
+	msg := clearSign(t, releaser, testShasums)
+	text, signer, err := keyring.VerifyClearsigned(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer != fingerprint(releaser) {
+		t.Errorf("signer = %s, want %s", signer, fingerprint(releaser))
+	}
+	sums, err := ParseChecksums(text)
+	if err != nil || sums["node-v20.12.0-linux-x64.tar.xz"] != sumWorld {
+		t.Errorf("signed checksums = %v, %v", sums, err)
+	}
+
+	tampered := bytes.Replace(msg, []byte(sumHello), []byte(sumWorld), 1)
+	if _, _, err := keyring.VerifyClearsigned(tampered); err == nil {
+		t.Error("tampered SHASUMS256.txt.asc accepted")
+	}
+	if _, _, err := keyring.VerifyClearsigned(clearSign(t, stranger, testShasums)); err == nil {
+		t.Error("message signed by a key outside the keyring accepted")
+	}
+	if _, _, err := keyring.VerifyClearsigned([]byte(testShasums)); err == nil {
+		t.Error("unsigned SHASUMS256.txt accepted")
+	}
+}
@@ -0,0 +1,192 @@
+package version
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/joaomarcosfurtado/gnode/internal/httpclient"
+)
+
+type indexMeta struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+func (s *Service) indexPath() string {
+	return filepath.Join(s.cacheDir, "index.json")
+}
+
+func (s *Service) metaPath() string {
+	return filepath.Join(s.cacheDir, "index.meta.json")
+}
+
+func (s *Service) readCache() ([]NodeVersion, *indexMeta, error) {
+	data, err := os.ReadFile(s.indexPath())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	versions, err := decodeIndex(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	meta := &indexMeta{}
+	if raw, err := os.ReadFile(s.metaPath()); err == nil {
+		json.Unmarshal(raw, meta)
+	}
+
+	// An index fetched from a mirror that is no longer configured is not a
+	// valid base for conditional requests.
+	if !s.isIndexURL(meta.URL) {
+		meta = &indexMeta{}
+	}
+
+	return versions, meta, nil
+}
+
+func (s *Service) writeCache(data []byte, meta *indexMeta) error {
+	if err := os.MkdirAll(s.cacheDir, 0755); err != nil {
+		return fmt.Errorf("error creating cache directory: %v", err)
+	}
+
+	if err := writeFileAtomic(s.indexPath(), data); err != nil {
+		return fmt.Errorf("error writing index cache: %v", err)
+	}
+
+	return s.writeMeta(meta)
+}
+
+func (s *Service) writeMeta(meta *indexMeta) error {
+	raw, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.metaPath(), raw); err != nil {
+		return fmt.Errorf("error writing index metadata: %v", err)
+	}
+	return nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func decodeIndex(data []byte) ([]NodeVersion, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '<' {
+		return nil, fmt.Errorf("received HTML instead of index.json")
+	}
+
+	var versions []NodeVersion
+	if err := json.Unmarshal(trimmed, &versions); err != nil {
+		return nil, fmt.Errorf("error decoding JSON %v", err)
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("index.json lists no versions")
+	}
+	return versions, nil
+}
+
+// fetchIndex downloads index.json from the first mirror that answers,
+// failing over on connection errors, 5xx responses and corrupt indexes.
+func (s *Service) fetchIndex(cached []NodeVersion, meta *indexMeta) ([]NodeVersion, error) {
+	var lastErr error
+	for i, mirror := range s.mirrors {
+		versions, err := s.fetchIndexFrom(mirror, cached, meta)
+		if err == nil {
+			s.baseURL = mirror
+			return versions, nil
+		}
+
+		lastErr = err
+		if !httpclient.ShouldFailover(err) {
+			break
+		}
+		if i < len(s.mirrors)-1 {
+			fmt.Fprintf(os.Stderr, "Warning: %v; trying next mirror\n", err)
+		}
+	}
+	return nil, fmt.Errorf("error getting remote versions: %v", lastErr)
+}
+
+// fetchIndexFrom downloads index.json from one mirror, revalidating the
+// cached copy with If-None-Match/If-Modified-Since when it came from there.
+func (s *Service) fetchIndexFrom(mirror string, cached []NodeVersion, meta *indexMeta) ([]NodeVersion, error) {
+	url := indexURL(mirror)
+	req, err := s.client.NewRequest(http.MethodGet, url)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached != nil && meta.URL == url {
+		if meta.ETag != "" {
+			req.Header.Set("If-None-Match", meta.ETag)
+		}
+		if meta.LastModified != "" {
+			req.Header.Set("If-Modified-Since", meta.LastModified)
+		}
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil && meta.URL == url:
+		meta.FetchedAt = time.Now()
+		if err := s.writeMeta(meta); err != nil {
+			return nil, err
+		}
+		return cached, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, &httpclient.StatusError{URL: url, Code: resp.StatusCode}
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", url, err)
+	}
+
+	versions, err := decodeIndex(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid index from %s: %v", url, err)
+	}
+
+	newMeta := &indexMeta{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    time.Now(),
+	}
+	if err := s.writeCache(data, newMeta); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	return versions, nil
+}
@@ -0,0 +1,97 @@
+package version
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	ChannelRelease  = "release"
+	ChannelRC       = "rc"
+	ChannelNightly  = "nightly"
+	ChannelTest     = "test"
+	ChannelV8Canary = "v8-canary"
+)
+
+var Channels = []string{ChannelRC, ChannelNightly, ChannelTest, ChannelV8Canary}
+
+func IsChannel(name string) bool {
+	for _, c := range Channels {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitChannel separates a channel-qualified spec or installed version such
+// as "rc/22" or "nightly/v23.0.0-nightly2024..." into its channel and the
+// rest. Plain specs belong to the release channel.
+func SplitChannel(spec string) (string, string) {
+	name, rest, ok := strings.Cut(spec, "/")
+	if ok && IsChannel(strings.ToLower(name)) {
+		return strings.ToLower(name), rest
+	}
+	return ChannelRelease, spec
+}
+
+func JoinChannel(channel, version string) string {
+	if channel == "" || channel == ChannelRelease {
+		return version
+	}
+	return channel + "/" + version
+}
+
+// SplitFlavor separates the build flavor suffix of an installed version
+// name, so "v20.18.0+musl" becomes "v20.18.0" and "musl".
+func SplitFlavor(id string) (string, string) {
+	v, flavor, _ := strings.Cut(id, "+")
+	return v, flavor
+}
+
+func JoinFlavor(version, flavor string) string {
+	if flavor == "" {
+		return version
+	}
+	return version + "+" + flavor
+}
+
+// MaxSatisfyingPrerelease is MaxSatisfying for pre-release channels: a
+// version matches when its major.minor.patch falls in the range, whatever
+// its pre-release tag, and the newest build wins.
+func MaxSatisfyingPrerelease(versions []string, spec string) (string, error) {
+	if strings.EqualFold(spec, "latest") || strings.EqualFold(spec, "node") {
+		spec = "*"
+	}
+
+	if IsExactVersion(spec) {
+		return MaxSatisfying(versions, spec)
+	}
+
+	r, err := ParseRange(spec)
+	if err != nil {
+		return "", err
+	}
+
+	best := ""
+	var bestVer Semver
+	for _, v := range versions {
+		sv, err := ParseSemver(v)
+		if err != nil {
+			continue
+		}
+		core := sv
+		core.Prerelease = ""
+		if !r.Match(core) {
+			continue
+		}
+		if best == "" || sv.Compare(bestVer) > 0 {
+			best, bestVer = v, sv
+		}
+	}
+
+	if best == "" {
+		return "", fmt.Errorf("no version matching %s", spec)
+	}
+	return best, nil
+}
@@ -0,0 +1,128 @@
+package version
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LTS holds the release codename from index.json, which is either false or a
+// string such as "Iron". Non-LTS releases are stored as an empty string.
+type LTS string
+
+func (l *LTS) UnmarshalJSON(data []byte) error {
+	if string(data) == "false" || string(data) == "null" {
+		*l = ""
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid lts value %s", string(data))
+	}
+	*l = LTS(s)
+	return nil
+}
+
+func (l LTS) MarshalJSON() ([]byte, error) {
+	if l == "" {
+		return []byte("false"), nil
+	}
+	return json.Marshal(string(l))
+}
+
+func (l LTS) IsLTS() bool {
+	return l != ""
+}
+
+func IsAlias(spec string) bool {
+	s := strings.ToLower(strings.TrimSpace(spec))
+	return s == "latest" || s == "node" || strings.HasPrefix(s, "lts/")
+}
+
+// FilterAlias returns the releases that belong to the line an alias refers
+// to: every release for "latest"/"node", or every release of one LTS codename
+// for "lts/*", "lts/<codename>" and "lts/-N".
+func FilterAlias(versions []NodeVersion, spec string) ([]NodeVersion, error) {
+	s := strings.ToLower(strings.TrimSpace(spec))
+
+	if s == "latest" || s == "node" {
+		return versions, nil
+	}
+
+	name := strings.TrimPrefix(s, "lts/")
+	codenames := ltsCodenames(versions)
+	if len(codenames) == 0 {
+		return nil, fmt.Errorf("no LTS releases found")
+	}
+
+	codename := ""
+	switch {
+	case name == "*":
+		codename = codenames[0]
+	case strings.HasPrefix(name, "-"):
+		n, err := strconv.Atoi(name[1:])
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid LTS alias %s", spec)
+		}
+		if n >= len(codenames) {
+			return nil, fmt.Errorf("there are only %d LTS lines, %s is out of range", len(codenames), spec)
+		}
+		codename = codenames[n]
+	default:
+		for _, c := range codenames {
+			if c == name {
+				codename = c
+				break
+			}
+		}
+		if codename == "" {
+			return nil, fmt.Errorf("unknown LTS codename %q", name)
+		}
+	}
+
+	var filtered []NodeVersion
+	for _, v := range versions {
+		if strings.ToLower(string(v.LTS)) == codename {
+			filtered = append(filtered, v)
+		}
+	}
+	return filtered, nil
+}
+
+// ltsCodenames returns the lowercase LTS codenames, newest line first.
+func ltsCodenames(versions []NodeVersion) []string {
+	newest := map[string]Semver{}
+	for _, v := range versions {
+		if !v.LTS.IsLTS() {
+			continue
+		}
+		sv, err := ParseSemver(v.Version)
+		if err != nil {
+			continue
+		}
+		name := strings.ToLower(string(v.LTS))
+		if cur, ok := newest[name]; !ok || sv.Compare(cur) > 0 {
+			newest[name] = sv
+		}
+	}
+
+	names := make([]string, 0, len(newest))
+	for name := range newest {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return newest[names[i]].Compare(newest[names[j]]) > 0
+	})
+	return names
+}
+
+func versionNames(versions []NodeVersion) []string {
+	names := make([]string, 0, len(versions))
+	for _, v := range versions {
+		names = append(names, v.Version)
+	}
+	return names
+}
@@ -0,0 +1,119 @@
+package version
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	SupportUnreleased  = "unreleased"
+	SupportCurrent     = "current"
+	SupportActiveLTS   = "active lts"
+	SupportMaintenance = "maintenance"
+	SupportEOL         = "eol"
+	SupportUnknown     = "unknown"
+)
+
+// embeddedSchedule is a copy of schedule.json from the nodejs/Release
+// repository, used until a fresher copy is downloaded.
+//
+//go:embed schedule.json
+var embeddedSchedule []byte
+
+// ScheduleEntry holds the support dates of one release line. Odd-numbered
+// lines never enter LTS and leave LTS empty.
+type ScheduleEntry struct {
+	Start       string `json:"start"`
+	LTS         string `json:"lts,omitempty"`
+	Maintenance string `json:"maintenance,omitempty"`
+	End         string `json:"end"`
+	Codename    string `json:"codename,omitempty"`
+}
+
+// Schedule maps release lines ("v18", "v0.12") to their support dates.
+type Schedule map[string]ScheduleEntry
+
+func ParseSchedule(data []byte) (Schedule, error) {
+	var schedule Schedule
+	if err := json.Unmarshal(data, &schedule); err != nil {
+		return nil, fmt.Errorf("error decoding schedule: %v", err)
+	}
+	if len(schedule) == 0 {
+		return nil, fmt.Errorf("schedule lists no release lines")
+	}
+	return schedule, nil
+}
+
+func DefaultSchedule() Schedule {
+	schedule, err := ParseSchedule(embeddedSchedule)
+	if err != nil {
+		panic(err)
+	}
+	return schedule
+}
+
+// Merge returns a schedule with the lines of other added to s, replacing
+// lines both of them list.
+func (s Schedule) Merge(other Schedule) Schedule {
+	merged := Schedule{}
+	for line, entry := range s {
+		merged[line] = entry
+	}
+	for line, entry := range other {
+		merged[line] = entry
+	}
+	return merged
+}
+
+// LineOf returns the schedule key of a version: "v18" for v18.20.4, and
+// "v0.12" for the pre-io.js v0.x lines.
+func LineOf(version string) (string, error) {
+	_, v := SplitChannel(version)
+	v, _ = SplitFlavor(v)
+	sv, err := ParseSemver(v)
+	if err != nil {
+		return "", err
+	}
+	if sv.Major == 0 {
+		return fmt.Sprintf("v0.%d", sv.Minor), nil
+	}
+	return fmt.Sprintf("v%d", sv.Major), nil
+}
+
+// Lookup returns the line and schedule entry a version belongs to.
+func (s Schedule) Lookup(version string) (string, ScheduleEntry, bool) {
+	line, err := LineOf(version)
+	if err != nil {
+		return "", ScheduleEntry{}, false
+	}
+	entry, ok := s[line]
+	return line, entry, ok
+}
+
+// Status returns the support phase of the line on a given day.
+func (e ScheduleEntry) Status(now time.Time) string {
+	today := now.Format("2006-01-02")
+	switch {
+	case e.Start != "" && today < e.Start:
+		return SupportUnreleased
+	case e.End != "" && today >= e.End:
+		return SupportEOL
+	case e.Maintenance != "" && today >= e.Maintenance:
+		return SupportMaintenance
+	case e.LTS != "" && today >= e.LTS:
+		return SupportActiveLTS
+	}
+	return SupportCurrent
+}
+
+// SupportStatus returns the support phase of the line a version belongs to,
+// or SupportUnknown when the schedule does not list it.
+func (s Schedule) SupportStatus(version string, now time.Time) string {
+	_, entry, ok := s.Lookup(version)
+	if !ok {
+		return SupportUnknown
+	}
+	return entry.Status(now)
+}
@@ -0,0 +1,41 @@
+package version
+
+import "sort"
+
+// SecurityFixes returns the security releases of the line v belongs to that
+// are newer than v, newest first. A non-empty result means v has known
+// vulnerabilities fixed by the first entry.
+func SecurityFixes(versions []NodeVersion, v string) []NodeVersion {
+	line, err := LineOf(v)
+	if err != nil {
+		return nil
+	}
+	_, bare := SplitChannel(v)
+	bare, _ = SplitFlavor(bare)
+	installed, err := ParseSemver(bare)
+	if err != nil {
+		return nil
+	}
+
+	var fixes []NodeVersion
+	for _, candidate := range versions {
+		if !candidate.Security {
+			continue
+		}
+		sv, err := ParseSemver(candidate.Version)
+		if err != nil || sv.Prerelease != "" || sv.Compare(installed) <= 0 {
+			continue
+		}
+		if l, _ := LineOf(candidate.Version); l != line {
+			continue
+		}
+		fixes = append(fixes, candidate)
+	}
+
+	sort.SliceStable(fixes, func(i, j int) bool {
+		a, _ := ParseSemver(fixes[i].Version)
+		b, _ := ParseSemver(fixes[j].Version)
+		return a.Compare(b) > 0
+	})
+	return fixes
+}
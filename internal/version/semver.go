@@ -0,0 +1,487 @@
+package version
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Semver struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+}
+
+func ParseSemver(s string) (Semver, error) {
+	var v Semver
+
+	str := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if str == "" {
+		return v, fmt.Errorf("invalid version: %q", s)
+	}
+
+	if i := strings.Index(str, "+"); i >= 0 {
+		str = str[:i]
+	}
+	if i := strings.Index(str, "-"); i >= 0 {
+		v.Prerelease = str[i+1:]
+		str = str[:i]
+	}
+
+	parts := strings.Split(str, ".")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("invalid version: %q", s)
+	}
+
+	nums := make([]int, 3)
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version: %q", s)
+		}
+		nums[i] = n
+	}
+
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+	return v, nil
+}
+
+func (v Semver) String() string {
+	s := fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+func (v Semver) Compare(o Semver) int {
+	if c := compareInt(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+	return comparePrerelease(v.Prerelease, o.Prerelease)
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func comparePrerelease(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return 1
+	}
+	if b == "" {
+		return -1
+	}
+
+	ap := strings.Split(a, ".")
+	bp := strings.Split(b, ".")
+	for i := 0; i < len(ap) && i < len(bp); i++ {
+		an, aErr := strconv.Atoi(ap[i])
+		bn, bErr := strconv.Atoi(bp[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if c := compareInt(an, bn); c != 0 {
+				return c
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(ap[i], bp[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInt(len(ap), len(bp))
+}
+
+// SortVersions orders installed version names oldest first, with release
+// versions ahead of channel-qualified ones such as "rc/v22.0.0-rc.1".
+func SortVersions(versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		ci, vi := SplitChannel(versions[i])
+		cj, vj := SplitChannel(versions[j])
+		if ci != cj {
+			if ci == ChannelRelease || cj == ChannelRelease {
+				return ci == ChannelRelease
+			}
+			return ci < cj
+		}
+
+		a, aErr := ParseSemver(vi)
+		b, bErr := ParseSemver(vj)
+		switch {
+		case aErr != nil && bErr != nil:
+			return vi < vj
+		case aErr != nil || bErr != nil:
+			return aErr == nil
+		}
+		return a.Compare(b) < 0
+	})
+}
+
+type comparator struct {
+	op      string
+	version Semver
+}
+
+func (c comparator) match(v Semver) bool {
+	cmp := v.Compare(c.version)
+	switch c.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	default:
+		return cmp == 0
+	}
+}
+
+type Range struct {
+	sets [][]comparator
+}
+
+func ParseRange(spec string) (*Range, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("empty version range")
+	}
+
+	r := &Range{}
+	for _, part := range strings.Split(spec, "||") {
+		set, err := parseComparatorSet(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version range %q: %v", spec, err)
+		}
+		r.sets = append(r.sets, set)
+	}
+	return r, nil
+}
+
+func (r *Range) Match(v Semver) bool {
+	for _, set := range r.sets {
+		if matchSet(set, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSet(set []comparator, v Semver) bool {
+	for _, c := range set {
+		if !c.match(v) {
+			return false
+		}
+	}
+
+	if v.Prerelease == "" {
+		return true
+	}
+
+	// Prereleases only match when a comparator in the same set explicitly
+	// names a prerelease of the same major.minor.patch, as npm does.
+	for _, c := range set {
+		cv := c.version
+		if cv.Prerelease != "" && cv.Major == v.Major && cv.Minor == v.Minor && cv.Patch == v.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+func parseComparatorSet(s string) ([]comparator, error) {
+	tokens := tokenizeRange(s)
+	if len(tokens) == 0 {
+		return []comparator{{op: ">=", version: Semver{}}}, nil
+	}
+
+	if len(tokens) == 3 && tokens[1] == "-" {
+		lower, err := parsePartial(tokens[0])
+		if err != nil {
+			return nil, err
+		}
+		upper, err := parsePartial(tokens[2])
+		if err != nil {
+			return nil, err
+		}
+		return hyphenRange(lower, upper), nil
+	}
+
+	var set []comparator
+	for _, tok := range tokens {
+		cs, err := parseComparator(tok)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, cs...)
+	}
+	return set, nil
+}
+
+// tokenizeRange splits a comparator set on whitespace and glues operators
+// written apart from their version (">= 18") back onto it.
+func tokenizeRange(s string) []string {
+	var tokens []string
+	fields := strings.Fields(s)
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		if isOperator(f) && i+1 < len(fields) {
+			f += fields[i+1]
+			i++
+		}
+		tokens = append(tokens, f)
+	}
+	return tokens
+}
+
+func isOperator(s string) bool {
+	switch s {
+	case "<", "<=", ">", ">=", "=", "^", "~", "~>":
+		return true
+	}
+	return false
+}
+
+type partial struct {
+	major, minor, patch int
+	prerelease          string
+	// parts is the number of leading numeric components that were given,
+	// wildcards excluded: "20" has 1, "20.x" has 1, "20.1.2" has 3.
+	parts int
+}
+
+func parsePartial(s string) (partial, error) {
+	p := partial{}
+
+	str := strings.TrimPrefix(strings.TrimPrefix(s, "="), "v")
+	if i := strings.Index(str, "+"); i >= 0 {
+		str = str[:i]
+	}
+	if i := strings.Index(str, "-"); i >= 0 {
+		p.prerelease = str[i+1:]
+		str = str[:i]
+	}
+
+	if str == "" || str == "*" || str == "x" || str == "X" {
+		return p, nil
+	}
+
+	fields := strings.Split(str, ".")
+	if len(fields) > 3 {
+		return p, fmt.Errorf("invalid version %q", s)
+	}
+
+	nums := []*int{&p.major, &p.minor, &p.patch}
+	for i, f := range fields {
+		if f == "*" || f == "x" || f == "X" {
+			break
+		}
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return p, fmt.Errorf("invalid version %q", s)
+		}
+		*nums[i] = n
+		p.parts = i + 1
+	}
+
+	if p.parts < 3 {
+		p.prerelease = ""
+	}
+	return p, nil
+}
+
+func (p partial) semver() Semver {
+	return Semver{Major: p.major, Minor: p.minor, Patch: p.patch, Prerelease: p.prerelease}
+}
+
+// upper returns the exclusive upper bound of a partial version: "20" becomes
+// <21.0.0-0 and "20.11" becomes <20.12.0-0. The "-0" keeps prereleases of the
+// next line out of the range.
+func (p partial) upper() Semver {
+	switch p.parts {
+	case 1:
+		return Semver{Major: p.major + 1, Prerelease: "0"}
+	case 2:
+		return Semver{Major: p.major, Minor: p.minor + 1, Prerelease: "0"}
+	}
+	return p.semver()
+}
+
+func parseComparator(tok string) ([]comparator, error) {
+	op := ""
+	for _, candidate := range []string{"<=", ">=", "~>", "<", ">", "=", "^", "~"} {
+		if strings.HasPrefix(tok, candidate) {
+			op = candidate
+			break
+		}
+	}
+
+	p, err := parsePartial(strings.TrimPrefix(tok, op))
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "^":
+		return caretRange(p), nil
+	case "~", "~>":
+		return tildeRange(p), nil
+	case "", "=":
+		return xRange(p), nil
+	case ">":
+		if p.parts == 0 {
+			return []comparator{{op: "<", version: Semver{}}}, nil
+		}
+		if p.parts < 3 {
+			return []comparator{{op: ">=", version: p.upper()}}, nil
+		}
+		return []comparator{{op: ">", version: p.semver()}}, nil
+	case ">=":
+		return []comparator{{op: ">=", version: p.semver()}}, nil
+	case "<":
+		if p.parts == 0 {
+			return []comparator{{op: "<", version: Semver{}}}, nil
+		}
+		return []comparator{{op: "<", version: Semver{Major: p.major, Minor: p.minor, Patch: p.patch, Prerelease: prereleaseOrZero(p)}}}, nil
+	case "<=":
+		if p.parts == 0 {
+			return []comparator{{op: ">=", version: Semver{}}}, nil
+		}
+		if p.parts < 3 {
+			return []comparator{{op: "<", version: p.upper()}}, nil
+		}
+		return []comparator{{op: "<=", version: p.semver()}}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported operator %q", op)
+}
+
+func prereleaseOrZero(p partial) string {
+	if p.parts < 3 {
+		return "0"
+	}
+	return p.prerelease
+}
+
+func xRange(p partial) []comparator {
+	if p.parts == 0 {
+		return []comparator{{op: ">=", version: Semver{}}}
+	}
+	if p.parts == 3 {
+		return []comparator{{op: "=", version: p.semver()}}
+	}
+	return []comparator{
+		{op: ">=", version: p.semver()},
+		{op: "<", version: p.upper()},
+	}
+}
+
+func caretRange(p partial) []comparator {
+	if p.parts == 0 {
+		return []comparator{{op: ">=", version: Semver{}}}
+	}
+
+	var upper Semver
+	switch {
+	case p.major != 0 || p.parts == 1:
+		upper = Semver{Major: p.major + 1, Prerelease: "0"}
+	case p.minor != 0 || p.parts == 2:
+		upper = Semver{Major: 0, Minor: p.minor + 1, Prerelease: "0"}
+	default:
+		upper = Semver{Major: 0, Minor: 0, Patch: p.patch + 1, Prerelease: "0"}
+	}
+
+	return []comparator{
+		{op: ">=", version: p.semver()},
+		{op: "<", version: upper},
+	}
+}
+
+func tildeRange(p partial) []comparator {
+	if p.parts == 0 {
+		return []comparator{{op: ">=", version: Semver{}}}
+	}
+
+	upper := Semver{Major: p.major, Minor: p.minor + 1, Prerelease: "0"}
+	if p.parts == 1 {
+		upper = Semver{Major: p.major + 1, Prerelease: "0"}
+	}
+
+	return []comparator{
+		{op: ">=", version: p.semver()},
+		{op: "<", version: upper},
+	}
+}
+
+func hyphenRange(lower, upper partial) []comparator {
+	set := []comparator{{op: ">=", version: lower.semver()}}
+	switch {
+	case upper.parts == 0:
+	case upper.parts < 3:
+		set = append(set, comparator{op: "<", version: upper.upper()})
+	default:
+		set = append(set, comparator{op: "<=", version: upper.semver()})
+	}
+	return set
+}
+
+func IsExactVersion(spec string) bool {
+	_, err := ParseSemver(spec)
+	return err == nil
+}
+
+func MaxSatisfying(versions []string, spec string) (string, error) {
+	if IsExactVersion(spec) {
+		want, _ := ParseSemver(spec)
+		for _, v := range versions {
+			if sv, err := ParseSemver(v); err == nil && sv.Compare(want) == 0 {
+				return v, nil
+			}
+		}
+		return "", fmt.Errorf("no version matching %s", spec)
+	}
+
+	r, err := ParseRange(spec)
+	if err != nil {
+		return "", err
+	}
+
+	best := ""
+	var bestVer Semver
+	for _, v := range versions {
+		sv, err := ParseSemver(v)
+		if err != nil || !r.Match(sv) {
+			continue
+		}
+		if best == "" || sv.Compare(bestVer) > 0 {
+			best, bestVer = v, sv
+		}
+	}
+
+	if best == "" {
+		return "", fmt.Errorf("no version matching %s", spec)
+	}
+	return best, nil
+}
@@ -0,0 +1,241 @@
+package version
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSemver(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Semver
+		wantErr bool
+	}{
+		{in: "v20.12.0", want: Semver{Major: 20, Minor: 12}},
+		{in: "1.2.3", want: Semver{Major: 1, Minor: 2, Patch: 3}},
+		{in: " v1.2.3 ", want: Semver{Major: 1, Minor: 2, Patch: 3}},
+		{in: "v22.0.0-rc.1", want: Semver{Major: 22, Prerelease: "rc.1"}},
+		{in: "v1.2.3+build.5", want: Semver{Major: 1, Minor: 2, Patch: 3}},
+		{in: "v1.2.3-beta+build", want: Semver{Major: 1, Minor: 2, Patch: 3, Prerelease: "beta"}},
+		{in: "", wantErr: true},
+		{in: "v20", wantErr: true},
+		{in: "v20.1", wantErr: true},
+		{in: "v1.2.3.4", wantErr: true},
+		{in: "v1.x.3", wantErr: true},
+		{in: "v1.-2.3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSemver(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseSemver(%q) = %v, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSemver(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestSemverCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2.3", "1.2.4", -1},
+		{"1.10.0", "1.9.0", 1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-rc.1", "1.0.0-beta.11", 1},
+		{"1.0.0-0", "1.0.0-alpha", -1},
+	}
+
+	for _, tt := range tests {
+		a, _ := ParseSemver(tt.a)
+		b, _ := ParseSemver(tt.b)
+		if got := a.Compare(b); got != tt.want {
+			t.Errorf("Compare(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := b.Compare(a); got != -tt.want {
+			t.Errorf("Compare(%s, %s) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestRangeMatch(t *testing.T) {
+	tests := []struct {
+		spec  string
+		match []string
+		miss  []string
+	}{
+		// x-ranges and partial versions
+		{"*", []string{"0.0.0", "22.1.0"}, []string{"22.0.0-rc.1"}},
+		{"x", []string{"1.0.0"}, nil},
+		{"20", []string{"20.0.0", "20.99.9"}, []string{"19.9.9", "21.0.0", "21.0.0-rc.1"}},
+		{"20.x", []string{"20.0.0", "20.12.2"}, []string{"21.0.0"}},
+		{"20.11", []string{"20.11.0", "20.11.9"}, []string{"20.10.9", "20.12.0"}},
+		{"20.11.x", []string{"20.11.1"}, []string{"20.12.0"}},
+		{"=20.11.1", []string{"20.11.1"}, []string{"20.11.2"}},
+		{"v20.11.1", []string{"20.11.1"}, []string{"20.11.0"}},
+
+		// caret, including the 0.x rules
+		{"^1.2.3", []string{"1.2.3", "1.9.0"}, []string{"1.2.2", "2.0.0", "2.0.0-0"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0", "0.2.2"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4", "0.0.2"}},
+		{"^0.0", []string{"0.0.0", "0.0.9"}, []string{"0.1.0"}},
+		{"^0", []string{"0.0.0", "0.9.9"}, []string{"1.0.0"}},
+		{"^1.x", []string{"1.0.0", "1.5.0"}, []string{"2.0.0"}},
+		{"^1.2.3-beta.2", []string{"1.2.3-beta.2", "1.2.3-beta.4", "1.2.3", "1.3.0"}, []string{"1.2.3-beta.1", "1.3.0-beta"}},
+
+		// tilde, including 0.x
+		{"~1.2.3", []string{"1.2.3", "1.2.9"}, []string{"1.3.0", "1.2.2"}},
+		{"~1.2", []string{"1.2.0", "1.2.9"}, []string{"1.3.0"}},
+		{"~1", []string{"1.0.0", "1.9.9"}, []string{"2.0.0"}},
+		{"~0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0"}},
+		{"~0.0.3", []string{"0.0.3", "0.0.9"}, []string{"0.1.0"}},
+		{"~>1.2.3", []string{"1.2.5"}, []string{"1.3.0"}},
+
+		// hyphen ranges, with partial bounds
+		{"1.2.3 - 2.3.4", []string{"1.2.3", "2.3.4"}, []string{"1.2.2", "2.3.5"}},
+		{"1.2 - 2.3.4", []string{"1.2.0", "2.3.4"}, []string{"1.1.9", "2.3.5"}},
+		{"1.2.3 - 2.3", []string{"2.3.9"}, []string{"2.4.0", "2.4.0-0"}},
+		{"1.2.3 - 2", []string{"2.9.9"}, []string{"3.0.0"}},
+		{"18 - 20", []string{"18.0.0", "20.19.5"}, []string{"17.9.9", "21.0.0"}},
+
+		// primitive operators, with and without a space
+		{">=18", []string{"18.0.0", "22.0.0"}, []string{"17.9.9"}},
+		{">= 18", []string{"18.0.0"}, []string{"17.9.9"}},
+		{">=18 <20", []string{"18.0.0", "19.9.9"}, []string{"20.0.0", "17.0.0"}},
+		{">= 18 < 20", []string{"19.0.0"}, []string{"20.0.0"}},
+		{">18", []string{"19.0.0"}, []string{"18.9.9", "18.0.0"}},
+		{">18.1", []string{"18.2.0"}, []string{"18.1.9"}},
+		{">18.1.1", []string{"18.1.2"}, []string{"18.1.1"}},
+		{"<18", []string{"17.9.9"}, []string{"18.0.0", "18.0.0-rc.1"}},
+		{"<=18", []string{"18.9.9"}, []string{"19.0.0"}},
+		{"<=18.1.1", []string{"18.1.1"}, []string{"18.1.2"}},
+		{"<*", nil, []string{"0.0.0", "1.0.0"}},
+
+		// unions
+		{"^18 || ^20", []string{"18.1.0", "20.1.0"}, []string{"19.0.0", "22.0.0"}},
+		{"16.x||>=20.1.0", []string{"16.4.0", "20.1.0", "22.0.0"}, []string{"18.0.0", "20.0.9"}},
+		{"<16 || 18 - 20.5 || >=22", []string{"14.0.0", "20.5.9", "22.0.0"}, []string{"16.0.0", "20.6.0", "21.0.0"}},
+
+		// prereleases need a comparator naming the same major.minor.patch
+		{">=22.0.0-rc.1", []string{"22.0.0-rc.1", "22.0.0-rc.2", "22.0.0", "23.0.0"}, []string{"22.0.1-rc.1", "22.0.0-beta"}},
+		{">=20", nil, []string{"22.0.0-rc.1"}},
+		{"^22.0.0", []string{"22.0.0"}, []string{"22.1.0-nightly"}},
+		{"22.0.0-rc.1 - 22.0.0", []string{"22.0.0-rc.3"}, []string{"22.0.1"}},
+	}
+
+	for _, tt := range tests {
+		r, err := ParseRange(tt.spec)
+		if err != nil {
+			t.Errorf("ParseRange(%q): %v", tt.spec, err)
+			continue
+		}
+		for _, v := range tt.match {
+			if sv, _ := ParseSemver(v); !r.Match(sv) {
+				t.Errorf("%q should match %s", tt.spec, v)
+			}
+		}
+		for _, v := range tt.miss {
+			if sv, _ := ParseSemver(v); r.Match(sv) {
+				t.Errorf("%q should not match %s", tt.spec, v)
+			}
+		}
+	}
+}
+
+func TestParseRangeErrors(t *testing.T) {
+	for _, spec := range []string{"", "   ", "abc", ">=1.2.3.4", "^1.a", "^18 || lts"} {
+		if _, err := ParseRange(spec); err == nil {
+			t.Errorf("ParseRange(%q) should fail", spec)
+		}
+	}
+}
+
+func TestMaxSatisfying(t *testing.T) {
+	versions := []string{"v16.20.2", "v18.19.1", "v18.20.4", "v20.12.0", "v22.0.0-rc.1", "v22.0.0", "v22.3.0", "not-a-version"}
+
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{spec: "18", want: "v18.20.4"},
+		{spec: "^18.19", want: "v18.20.4"},
+		{spec: "~18.19", want: "v18.19.1"},
+		{spec: ">=16 <20", want: "v18.20.4"},
+		{spec: "*", want: "v22.3.0"},
+		{spec: "16 || 20", want: "v20.12.0"},
+		{spec: "v20.12.0", want: "v20.12.0"},
+		{spec: "22.0.0-rc.1", want: "v22.0.0-rc.1"},
+		{spec: ">=22.0.0-rc.1 <22.0.0", want: "v22.0.0-rc.1"},
+		{spec: "v20.11.0", wantErr: true},
+		{spec: "24", wantErr: true},
+		{spec: "garbage", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := MaxSatisfying(versions, tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("MaxSatisfying(%q) = %q, want error", tt.spec, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("MaxSatisfying(%q) = %q, %v, want %q", tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+func TestMaxSatisfyingPrerelease(t *testing.T) {
+	versions := []string{"v22.0.0-rc.1", "v22.0.0-rc.2", "v22.1.0-rc.1", "v23.0.0-rc.1", "v21.0.0-nightly20231010abcdef"}
+
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{spec: "22", want: "v22.1.0-rc.1"},
+		{spec: "22.0", want: "v22.0.0-rc.2"},
+		{spec: "latest", want: "v23.0.0-rc.1"},
+		{spec: "node", want: "v23.0.0-rc.1"},
+		{spec: "*", want: "v23.0.0-rc.1"},
+		{spec: "<22", want: "v21.0.0-nightly20231010abcdef"},
+		{spec: "^21 || 22.0", want: "v22.0.0-rc.2"},
+		{spec: "v22.0.0-rc.1", want: "v22.0.0-rc.1"},
+		{spec: "24", wantErr: true},
+		{spec: "v22.0.0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := MaxSatisfyingPrerelease(versions, tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("MaxSatisfyingPrerelease(%q) = %q, want error", tt.spec, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("MaxSatisfyingPrerelease(%q) = %q, %v, want %q", tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+func TestSortVersions(t *testing.T) {
+	versions := []string{"v20.1.0", "rc/v22.0.0-rc.1", "v18.20.4", "v20.1.0-rc.1", "nightly/v23.0.0-nightly", "v9.11.2", "custom"}
+	SortVersions(versions)
+
+	want := []string{"v9.11.2", "v18.20.4", "v20.1.0-rc.1", "v20.1.0", "custom", "nightly/v23.0.0-nightly", "rc/v22.0.0-rc.1"}
+	if !reflect.DeepEqual(versions, want) {
+		t.Errorf("SortVersions = %v, want %v", versions, want)
+	}
+}
@@ -1,42 +1,164 @@
 package version
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
+
+	"github.com/joaomarcosfurtado/gnode/internal/httpclient"
 )
 
 type NodeVersion struct {
-	Version string   `json:"version"`
-	Date    string   `json:"date"`
-	Files   []string `json:"files"`
+	Version  string   `json:"version"`
+	Date     string   `json:"date"`
+	Files    []string `json:"files"`
+	Npm      string   `json:"npm,omitempty"`
+	V8       string   `json:"v8"`
+	UV       string   `json:"uv,omitempty"`
+	Zlib     string   `json:"zlib,omitempty"`
+	OpenSSL  string   `json:"openssl,omitempty"`
+	Modules  string   `json:"modules,omitempty"`
+	LTS      LTS      `json:"lts"`
+	Security bool     `json:"security"`
 }
 
 type Service struct {
-	baseURL string
+	baseURL  string
+	mirrors  []string
+	channel  string
+	cacheDir string
+	ttl      time.Duration
+	refresh  bool
+	offline  bool
+	client   *httpclient.Client
+	versions []NodeVersion
 }
 
-func NewService(baseUrl string) *Service {
+// NewService creates a service for a download tree served by one or more
+// mirrors, tried in order. Download URLs point at the first mirror until an
+// index request fails over to another one.
+func NewService(mirrors []string, cacheDir string, ttl time.Duration) *Service {
 	return &Service{
-		baseURL: baseUrl,
+		baseURL:  mirrors[0],
+		mirrors:  mirrors,
+		channel:  ChannelRelease,
+		cacheDir: cacheDir,
+		ttl:      ttl,
+		client:   httpclient.Default(),
+	}
+}
+
+// SetChannel marks the service as serving a pre-release channel, whose
+// versions all carry a pre-release tag and are matched on major.minor.patch.
+func (s *Service) SetChannel(channel string) {
+	s.channel = channel
+}
+
+func (s *Service) Channel() string {
+	return s.channel
+}
+
+func (s *Service) maxSatisfying(versions []string, spec string) (string, error) {
+	if s.channel != ChannelRelease {
+		return MaxSatisfyingPrerelease(versions, spec)
+	}
+	return MaxSatisfying(versions, spec)
+}
+
+func (s *Service) matches(r *Range, sv Semver) bool {
+	if s.channel != ChannelRelease {
+		sv.Prerelease = ""
 	}
+	return r.Match(sv)
+}
+
+// SetRefresh makes the next ListRemote skip the TTL and revalidate the
+// cached index with the server.
+func (s *Service) SetRefresh(refresh bool) {
+	s.refresh = refresh
+}
+
+// ShasumsCacheDir returns where the checksum files of a release are cached.
+func (s *Service) ShasumsCacheDir(release string) string {
+	return filepath.Join(s.cacheDir, "shasums", release)
+}
+
+func (s *Service) SetClient(client *httpclient.Client) {
+	s.client = client
+}
+
+// SetOffline restricts the service to the cached index and disables every
+// request that would need the network.
+func (s *Service) SetOffline(offline bool) {
+	s.offline = offline
+}
+
+func indexURL(mirror string) string {
+	return mirror + "/index.json"
+}
+
+// isIndexURL reports whether url is the index of one of the mirrors.
+func (s *Service) isIndexURL(url string) bool {
+	for _, mirror := range s.mirrors {
+		if indexURL(mirror) == url {
+			return true
+		}
+	}
+	return false
 }
 
 func (s *Service) ListRemote() ([]NodeVersion, error) {
-	resp, err := http.Get(s.baseURL + "/index.json")
+	if s.versions != nil {
+		return s.versions, nil
+	}
+
+	cached, meta, err := s.readCache()
 	if err != nil {
-		return nil, fmt.Errorf("error getting remote versions: %v", err)
+		cached, meta = nil, &indexMeta{}
 	}
-	defer resp.Body.Close()
 
-	var versions []NodeVersion
-	if err := json.NewDecoder(resp.Body).Decode(&versions); err != nil {
-		return nil, fmt.Errorf("error decoding JSON %v", err)
+	if s.offline {
+		if cached == nil {
+			return nil, fmt.Errorf("offline mode: no cached index.json, run a command online first to populate it")
+		}
+		s.versions = cached
+		return cached, nil
+	}
+
+	if cached != nil && !s.refresh && s.isIndexURL(meta.URL) && time.Since(meta.FetchedAt) < s.ttl {
+		s.versions = cached
+		return cached, nil
+	}
+
+	versions, err := s.fetchIndex(cached, meta)
+	if err != nil {
+		if cached == nil {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v; using cached index\n", err)
+		versions = cached
 	}
+
+	s.versions = versions
 	return versions, nil
 }
 
+// CachedIndex returns the index already in memory or on disk, however old,
+// without touching the network. It is meant for best-effort hints.
+func (s *Service) CachedIndex() ([]NodeVersion, bool) {
+	if s.versions != nil {
+		return s.versions, true
+	}
+	cached, _, err := s.readCache()
+	if err != nil {
+		return nil, false
+	}
+	return cached, true
+}
+
 func (s *Service) NormalizeVersion(version string) string {
 	if !strings.HasPrefix(version, "v") {
 		return "v" + version
@@ -44,7 +166,149 @@ func (s *Service) NormalizeVersion(version string) string {
 	return version
 }
 
-func (s *Service) GetDownloadURL(version, goos, goarch string) string {
+func (s *Service) Find(version string) (*NodeVersion, error) {
+	versions, err := s.ListRemote()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range versions {
+		if versions[i].Version == version {
+			return &versions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("node.js %s not found in remote index", version)
+}
+
+func (s *Service) FilterRemote(spec string) ([]NodeVersion, error) {
+	versions, err := s.ListRemote()
+	if err != nil {
+		return nil, err
+	}
+
+	if IsAlias(spec) {
+		return FilterAlias(versions, spec)
+	}
+
+	r, err := ParseRange(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var filtered []NodeVersion
+	for _, v := range versions {
+		if sv, err := ParseSemver(v.Version); err == nil && s.matches(r, sv) {
+			filtered = append(filtered, v)
+		}
+	}
+	return filtered, nil
+}
+
+func (s *Service) ResolveRemote(spec string) (string, error) {
+	query := spec
+	var versions []NodeVersion
+	var err error
+	if IsAlias(spec) {
+		versions, err = s.FilterRemote(spec)
+		query = "*"
+	} else {
+		versions, err = s.ListRemote()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	resolved, err := s.maxSatisfying(versionNames(versions), query)
+	if err != nil {
+		return "", fmt.Errorf("no remote version matches %s", spec)
+	}
+	return resolved, nil
+}
+
+func (s *Service) ResolveLocal(spec string, installed []string) (string, error) {
+	if IsAlias(spec) {
+		// Installed directories carry no LTS metadata, so aliases are
+		// resolved to a release line using the remote index first.
+		versions, err := s.FilterRemote(spec)
+		if err != nil {
+			return "", err
+		}
+
+		inLine := map[string]bool{}
+		for _, v := range versions {
+			inLine[v.Version] = true
+		}
+
+		var candidates []string
+		for _, v := range installed {
+			if inLine[v] {
+				candidates = append(candidates, v)
+			}
+		}
+
+		resolved, err := s.maxSatisfying(candidates, "*")
+		if err != nil {
+			return "", fmt.Errorf("no installed version matches %s", spec)
+		}
+		return resolved, nil
+	}
+
+	resolved, err := s.maxSatisfying(installed, spec)
+	if err != nil {
+		return "", fmt.Errorf("no installed version matches %s", spec)
+	}
+	return resolved, nil
+}
+
+func NodeArch(goarch string) string {
+	switch goarch {
+	case "amd64":
+		return "x64"
+	case "386":
+		return "x86"
+	case "arm":
+		return "armv7l"
+	}
+	return goarch
+}
+
+// BuildKeys returns the index.json "files" entries that provide an
+// installable build for the platform. flavor selects a libc variant such as
+// "musl" and only applies to Linux.
+func BuildKeys(goos, goarch, flavor string) []string {
+	arch := NodeArch(goarch)
+	switch goos {
+	case "windows":
+		return []string{"win-" + arch + "-zip", "win-" + arch + "-exe"}
+	case "darwin":
+		return []string{"osx-" + arch + "-tar"}
+	}
+	if flavor != "" {
+		return []string{goos + "-" + arch + "-" + flavor}
+	}
+	return []string{goos + "-" + arch}
+}
+
+func (v NodeVersion) HasBuild(goos, goarch, flavor string) bool {
+	for _, key := range BuildKeys(goos, goarch, flavor) {
+		for _, f := range v.Files {
+			if f == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Tarball compressions published for Linux and macOS builds.
+const (
+	FormatGz = "gz"
+	FormatXz = "xz"
+)
+
+// GetDownloadURL returns the archive of a build: a .zip on Windows and a
+// tarball compressed as format (FormatGz or FormatXz) elsewhere.
+func (s *Service) GetDownloadURL(version, goos, goarch, flavor, format string) string {
 	platform := ""
 	switch goos {
 	case "windows":
@@ -65,11 +329,24 @@ func (s *Service) GetDownloadURL(version, goos, goarch string) string {
 		return fmt.Sprintf("%s/%s/%s", s.baseURL, version, filename)
 	}
 
+	if flavor != "" {
+		arch += "-" + flavor
+	}
+
 	ext := ".tar.gz"
+	if format == FormatXz {
+		ext = ".tar.xz"
+	}
 	filename := fmt.Sprintf("node-%s-%s-%s%s", version, platform, arch, ext)
 	return fmt.Sprintf("%s/%s/%s", s.baseURL, version, filename)
 }
 
+// GetShasumsURL returns the SHASUMS256.txt listing the SHA-256 of every
+// file of a release.
+func (s *Service) GetShasumsURL(version string) string {
+	return fmt.Sprintf("%s/%s/SHASUMS256.txt", s.baseURL, version)
+}
+
 func (s *Service) CheckAvailableFiles(version, goarch string) map[string]bool {
 	arch := goarch
 	if arch == "amd64" {
@@ -86,15 +363,24 @@ func (s *Service) CheckAvailableFiles(version, goarch string) map[string]bool {
 	}
 
 	available := make(map[string]bool)
+	if s.offline {
+		return available
+	}
 
 	for name, url := range urls {
-		resp, err := http.Head(url)
-		if err == nil && resp.StatusCode == 200 {
-			available[name] = true
-			resp.Body.Close()
-		} else {
-			available[name] = false
+		available[name] = false
+
+		req, err := s.client.NewRequest(http.MethodHead, url)
+		if err != nil {
+			continue
+		}
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			continue
 		}
+		resp.Body.Close()
+		available[name] = resp.StatusCode == 200
 	}
 
 	return available
@@ -1,22 +1,80 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
+	"time"
 )
 
 const (
-	APP_NAME      = "gnode"
-	NODE_DIST_URL = "https://nodejs.org/dist"
+	APP_NAME          = "gnode"
+	NODE_DIST_URL     = "https://nodejs.org/dist"
+	NODE_DOWNLOAD_URL = "https://nodejs.org/download"
+	UNOFFICIAL_URL    = "https://unofficial-builds.nodejs.org/download/release"
+	DEFAULT_INDEX_TTL = time.Hour
+	SCHEDULE_URL      = "https://raw.githubusercontent.com/nodejs/Release/main/schedule.json"
+
+	DEFAULT_CONNECTIONS = 4
+	MAX_CONNECTIONS     = 16
+
+	ARCHIVE_AUTO = "auto"
+	ARCHIVE_XZ   = "xz"
+	ARCHIVE_GZ   = "gz"
 )
 
 type Config struct {
-	HomeDir    string
-	AppDir     string
-	CurrentDir string
-	GOOS       string
-	GOARCH     string
+	HomeDir      string
+	AppDir       string
+	CurrentDir   string
+	GOOS         string
+	GOARCH       string
+	nodeArch     string
+	Libc         string
+	IndexTTL     time.Duration
+	RefreshIndex bool
+	Offline      bool
+	ScheduleURL  string
+	Connections  int
+
+	// ArchiveFormat picks the tarball compression on Linux and macOS:
+	// ARCHIVE_AUTO takes .tar.xz when the release publishes it.
+	ArchiveFormat string
+
+	Hardened        bool
+	VerifySignature bool
+	Keyring         string
+
+	CACert     string
+	ClientCert string
+	ClientKey  string
+	Proxy      string
+	Insecure   bool
+
+	Mirrors        map[string][]string
+	MirrorToken    string
+	MirrorUsername string
+	MirrorPassword string
+	NetrcFile      string
+}
+
+// fileSettings mirrors ~/.gnode/config.json. Every field is optional.
+type fileSettings struct {
+	mirrorSettings
+	securitySettings
+
+	IndexTTL    string `json:"index_ttl"`
+	Offline     bool   `json:"offline"`
+	Arch        string `json:"arch"`
+	Libc        string `json:"libc"`
+	ScheduleURL string `json:"schedule_url"`
+	Connections *int   `json:"connections"`
+
+	ArchiveFormat string `json:"archive_format"`
 }
 
 func NewConfig() (*Config, error) {
@@ -30,23 +88,152 @@ func NewConfig() (*Config, error) {
 
 	arch := runtime.GOARCH
 
-	return &Config{
-		HomeDir:    homeDir,
-		AppDir:     appDir,
-		CurrentDir: currentDir,
-		GOOS:       runtime.GOOS,
-		GOARCH:     arch,
-	}, nil
+	cfg := &Config{
+		HomeDir:       homeDir,
+		AppDir:        appDir,
+		CurrentDir:    currentDir,
+		GOOS:          runtime.GOOS,
+		GOARCH:        arch,
+		IndexTTL:      DEFAULT_INDEX_TTL,
+		Connections:   DEFAULT_CONNECTIONS,
+		ArchiveFormat: ARCHIVE_AUTO,
+	}
+
+	if err := cfg.load(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) load() error {
+	var settings fileSettings
+
+	data, err := os.ReadFile(c.ConfigFile())
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading %s: %v", c.ConfigFile(), err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return fmt.Errorf("error decoding %s: %v", c.ConfigFile(), err)
+		}
+	}
+
+	if err := c.loadMirrors(settings.mirrorSettings); err != nil {
+		return err
+	}
+
+	if err := c.loadSecurity(settings.securitySettings); err != nil {
+		return err
+	}
+
+	c.detectPlatform(settings)
+	if c.Libc != "" && c.Libc != LIBC_GLIBC && c.Libc != LIBC_MUSL {
+		return fmt.Errorf("invalid libc %q: expected %s or %s", c.Libc, LIBC_GLIBC, LIBC_MUSL)
+	}
+
+	c.Offline = settings.Offline
+	if offline := os.Getenv("GNODE_OFFLINE"); offline != "" {
+		c.Offline = isTruthy(offline)
+	}
+
+	c.ScheduleURL = SCHEDULE_URL
+	if settings.ScheduleURL != "" {
+		c.ScheduleURL = settings.ScheduleURL
+	}
+	if url := os.Getenv("GNODE_SCHEDULE_URL"); url != "" {
+		c.ScheduleURL = url
+	}
+
+	if settings.Connections != nil {
+		c.Connections = *settings.Connections
+	}
+	if n := os.Getenv("GNODE_CONNECTIONS"); n != "" {
+		connections, err := strconv.Atoi(n)
+		if err != nil {
+			return fmt.Errorf("invalid GNODE_CONNECTIONS %q: expected a number", n)
+		}
+		c.Connections = connections
+	}
+	if c.Connections < 1 || c.Connections > MAX_CONNECTIONS {
+		return fmt.Errorf("invalid connection count %d: expected 1 to %d", c.Connections, MAX_CONNECTIONS)
+	}
+
+	if settings.ArchiveFormat != "" {
+		c.ArchiveFormat = settings.ArchiveFormat
+	}
+	if format := os.Getenv("GNODE_ARCHIVE_FORMAT"); format != "" {
+		c.ArchiveFormat = format
+	}
+	switch c.ArchiveFormat {
+	case ARCHIVE_AUTO, ARCHIVE_XZ, ARCHIVE_GZ:
+	default:
+		return fmt.Errorf("invalid archive format %q: expected %s, %s or %s", c.ArchiveFormat, ARCHIVE_AUTO, ARCHIVE_XZ, ARCHIVE_GZ)
+	}
+
+	if ttl := os.Getenv("GNODE_INDEX_TTL"); ttl != "" {
+		settings.IndexTTL = ttl
+	}
+	if settings.IndexTTL != "" {
+		ttl, err := time.ParseDuration(settings.IndexTTL)
+		if err != nil || ttl < 0 {
+			return fmt.Errorf("invalid index TTL %q: use a duration such as 30m or 2h", settings.IndexTTL)
+		}
+		c.IndexTTL = ttl
+	}
+
+	return nil
+}
+
+func isTruthy(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+func (c *Config) ConfigFile() string {
+	return filepath.Join(c.AppDir, "config.json")
+}
+
+func (c *Config) CacheDir() string {
+	return filepath.Join(c.AppDir, "cache")
 }
 
 func (c *Config) VersionsDir() string {
 	return filepath.Join(c.AppDir, "versions")
 }
 
+func (c *Config) AliasDir() string {
+	return filepath.Join(c.AppDir, "alias")
+}
+
+func (c *Config) ScheduleCacheFile() string {
+	return filepath.Join(c.CacheDir(), "schedule.json")
+}
+
+func (c *Config) PartialDir() string {
+	return filepath.Join(c.CacheDir(), "partial")
+}
+
+func (c *Config) ArchiveCacheDir() string {
+	return filepath.Join(c.CacheDir(), "archives")
+}
+
 func (c *Config) GetVersionDir(version string) string {
 	return filepath.Join(c.VersionsDir(), version)
 }
 
 func (c *Config) GetDistURL() string {
-	return NODE_DIST_URL
+	return c.MirrorURLs(MIRROR_RELEASE)[0]
+}
+
+// IndexCacheDir returns where a channel's index.json is cached. The release
+// index sits directly in the cache directory.
+func (c *Config) IndexCacheDir(channel string) string {
+	if channel == "" || channel == "release" {
+		return c.CacheDir()
+	}
+	return filepath.Join(c.CacheDir(), channel)
 }
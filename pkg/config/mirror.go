@@ -0,0 +1,231 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	MIRROR_RELEASE    = "release"
+	MIRROR_UNOFFICIAL = "unofficial"
+)
+
+// MirrorChannels lists every download tree a mirror can be configured for.
+var MirrorChannels = []string{MIRROR_RELEASE, "rc", "nightly", "test", "v8-canary", MIRROR_UNOFFICIAL}
+
+// mirrorList accepts either a single URL or an ordered list of URLs.
+type mirrorList []string
+
+func (l *mirrorList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = splitMirrors(single)
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("mirror must be a URL or a list of URLs")
+	}
+	*l = list
+	return nil
+}
+
+type mirrorSettings struct {
+	Mirror      mirrorList            `json:"mirror"`
+	Mirrors     map[string]mirrorList `json:"mirrors"`
+	TokenEnv    string                `json:"mirror_token_env"`
+	UsernameEnv string                `json:"mirror_username_env"`
+	PasswordEnv string                `json:"mirror_password_env"`
+	Netrc       string                `json:"netrc"`
+}
+
+// loadMirrors applies mirror overrides. The release mirror comes from
+// GNODE_NODEJS_ORG_MIRROR, then NVM_NODEJS_ORG_MIRROR, then the config
+// file. Other channels use GNODE_<CHANNEL>_MIRROR or the "mirrors" map.
+// Every source accepts several mirrors (comma-separated in the
+// environment), tried in order.
+func (c *Config) loadMirrors(settings mirrorSettings) error {
+	c.Mirrors = map[string][]string{}
+	for channel, mirrors := range settings.Mirrors {
+		c.Mirrors[channel] = mirrors
+	}
+	if len(settings.Mirror) > 0 {
+		c.Mirrors[MIRROR_RELEASE] = settings.Mirror
+	}
+
+	for _, name := range []string{"NVM_NODEJS_ORG_MIRROR", "GNODE_NODEJS_ORG_MIRROR"} {
+		if mirror := os.Getenv(name); mirror != "" {
+			c.Mirrors[MIRROR_RELEASE] = splitMirrors(mirror)
+		}
+	}
+	for _, channel := range MirrorChannels[1:] {
+		env := "GNODE_" + strings.ToUpper(strings.ReplaceAll(channel, "-", "_")) + "_MIRROR"
+		if mirror := os.Getenv(env); mirror != "" {
+			c.Mirrors[channel] = splitMirrors(mirror)
+		}
+	}
+
+	for channel, mirrors := range c.Mirrors {
+		if !IsMirrorChannel(channel) {
+			return fmt.Errorf("unknown channel %q in mirrors", channel)
+		}
+
+		var cleaned []string
+		for _, mirror := range mirrors {
+			u, err := url.Parse(mirror)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("invalid %s mirror %q: expected an http(s) URL", channel, mirror)
+			}
+			cleaned = append(cleaned, strings.TrimRight(mirror, "/"))
+		}
+		if len(cleaned) == 0 {
+			delete(c.Mirrors, channel)
+			continue
+		}
+		c.Mirrors[channel] = cleaned
+	}
+
+	c.applyMirrorOrder()
+
+	c.MirrorToken = envOr(settings.TokenEnv, "GNODE_MIRROR_TOKEN")
+	c.MirrorUsername = envOr(settings.UsernameEnv, "GNODE_MIRROR_USERNAME")
+	c.MirrorPassword = envOr(settings.PasswordEnv, "GNODE_MIRROR_PASSWORD")
+
+	c.NetrcFile = settings.Netrc
+	if netrc := os.Getenv("NETRC"); netrc != "" {
+		c.NetrcFile = netrc
+	}
+	if c.NetrcFile == "" {
+		name := ".netrc"
+		if c.GOOS == "windows" {
+			name = "_netrc"
+		}
+		c.NetrcFile = filepath.Join(c.HomeDir, name)
+	}
+
+	return nil
+}
+
+func splitMirrors(value string) []string {
+	var mirrors []string
+	for _, mirror := range strings.Split(value, ",") {
+		if mirror = strings.TrimSpace(mirror); mirror != "" {
+			mirrors = append(mirrors, mirror)
+		}
+	}
+	return mirrors
+}
+
+func IsMirrorChannel(name string) bool {
+	for _, channel := range MirrorChannels {
+		if channel == name {
+			return true
+		}
+	}
+	return false
+}
+
+// envOr reads the variable the config file names, falling back to gnode's
+// own variable. Secrets are never stored in config.json itself.
+func envOr(configured, fallback string) string {
+	if configured != "" {
+		return os.Getenv(configured)
+	}
+	return os.Getenv(fallback)
+}
+
+// CustomMirrors returns every mirror URL that overrides a default, which are
+// the hosts mirror credentials may be sent to.
+func (c *Config) CustomMirrors() []string {
+	var mirrors []string
+	for _, list := range c.Mirrors {
+		mirrors = append(mirrors, list...)
+	}
+	return mirrors
+}
+
+// MirrorURLs returns the download trees of a channel ("release", a
+// pre-release channel or "unofficial") in the order they are tried.
+func (c *Config) MirrorURLs(channel string) []string {
+	if channel == "" {
+		channel = MIRROR_RELEASE
+	}
+	if mirrors := c.Mirrors[channel]; len(mirrors) > 0 {
+		return mirrors
+	}
+
+	switch channel {
+	case MIRROR_RELEASE:
+		return []string{NODE_DIST_URL}
+	case MIRROR_UNOFFICIAL:
+		return []string{UNOFFICIAL_URL}
+	}
+	return []string{NODE_DOWNLOAD_URL + "/" + channel}
+}
+
+func (c *Config) MirrorOrderFile() string {
+	return filepath.Join(c.AppDir, "mirrors.json")
+}
+
+func (c *Config) readMirrorOrder() map[string][]string {
+	order := map[string][]string{}
+	if data, err := os.ReadFile(c.MirrorOrderFile()); err == nil {
+		json.Unmarshal(data, &order)
+	}
+	return order
+}
+
+// applyMirrorOrder moves mirrors recorded by "gnode mirror probe" to the
+// front, fastest first. Mirrors the probe did not see keep their configured
+// order after them, and recorded mirrors no longer configured are ignored.
+func (c *Config) applyMirrorOrder() {
+	for channel, preferred := range c.readMirrorOrder() {
+		configured := c.Mirrors[channel]
+		if len(configured) < 2 {
+			continue
+		}
+
+		seen := map[string]bool{}
+		var ordered []string
+		for _, mirror := range preferred {
+			for _, candidate := range configured {
+				if candidate == mirror && !seen[mirror] {
+					ordered = append(ordered, mirror)
+					seen[mirror] = true
+				}
+			}
+		}
+		for _, mirror := range configured {
+			if !seen[mirror] {
+				ordered = append(ordered, mirror)
+			}
+		}
+		c.Mirrors[channel] = ordered
+	}
+}
+
+// SaveMirrorOrder records the preferred mirror order of a channel and
+// applies it to the running config.
+func (c *Config) SaveMirrorOrder(channel string, mirrors []string) error {
+	order := c.readMirrorOrder()
+	order[channel] = mirrors
+
+	data, err := json.MarshalIndent(order, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.AppDir, 0755); err != nil {
+		return fmt.Errorf("error creating %s: %v", c.AppDir, err)
+	}
+	if err := os.WriteFile(c.MirrorOrderFile(), data, 0644); err != nil {
+		return fmt.Errorf("error writing %s: %v", c.MirrorOrderFile(), err)
+	}
+
+	c.applyMirrorOrder()
+	return nil
+}
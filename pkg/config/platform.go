@@ -0,0 +1,101 @@
+package config
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	LIBC_GLIBC = "glibc"
+	LIBC_MUSL  = "musl"
+)
+
+// detectLibc reports which C library a Linux system uses. musl systems ship
+// a /lib/ld-musl-<arch>.so.1 loader and glibc ones an ld-linux or ld64
+// loader; only when neither is found is ldd asked, whose musl build prints
+// its name.
+func detectLibc() string {
+	if runtime.GOOS != "linux" {
+		return ""
+	}
+
+	if matches, _ := filepath.Glob("/lib/ld-musl-*.so.1"); len(matches) > 0 {
+		return LIBC_MUSL
+	}
+	for _, pattern := range []string{"/lib*/ld-linux*.so.*", "/lib*/ld64.so.*"} {
+		if matches, _ := filepath.Glob(pattern); len(matches) > 0 {
+			return LIBC_GLIBC
+		}
+	}
+
+	// musl's ldd exits non-zero for --version, so only the output matters.
+	out, _ := exec.Command("ldd", "--version").CombinedOutput()
+	if strings.Contains(strings.ToLower(string(out)), "musl") {
+		return LIBC_MUSL
+	}
+
+	return LIBC_GLIBC
+}
+
+// detectNodeArch maps GOARCH to the architecture names used in Node.js
+// download file names. 32-bit ARM is split into armv6l and armv7l, which Go
+// does not distinguish at runtime, so the kernel is asked.
+func detectNodeArch(goarch string) string {
+	switch goarch {
+	case "amd64":
+		return "x64"
+	case "386":
+		return "x86"
+	case "arm":
+		if runtime.GOOS == "linux" {
+			out, err := exec.Command("uname", "-m").Output()
+			if err == nil && strings.HasPrefix(strings.TrimSpace(string(out)), "armv6") {
+				return "armv6l"
+			}
+		}
+		return "armv7l"
+	}
+	return goarch
+}
+
+// detectPlatform applies the arch and libc overrides. Detection itself is
+// left to NodeArch and Flavor, so commands that never look at builds, such
+// as "gnode current" in a shell prompt, do not pay for it.
+func (c *Config) detectPlatform(settings fileSettings) {
+	c.nodeArch = settings.Arch
+	if arch := os.Getenv("GNODE_ARCH"); arch != "" {
+		c.nodeArch = arch
+	}
+
+	c.Libc = settings.Libc
+	if libc := os.Getenv("GNODE_LIBC"); libc != "" {
+		c.Libc = libc
+	}
+}
+
+// NodeArch is the architecture in Node.js download file names, such as x64
+// or armv7l.
+func (c *Config) NodeArch() string {
+	if c.nodeArch == "" {
+		c.nodeArch = detectNodeArch(c.GOARCH)
+	}
+	return c.nodeArch
+}
+
+// Flavor is the build variant suffix for this platform: "musl" on musl
+// systems and empty everywhere else.
+func (c *Config) Flavor() string {
+	if c.GOOS != "linux" {
+		return ""
+	}
+	if c.Libc == "" {
+		c.Libc = detectLibc()
+	}
+	if c.Libc == LIBC_MUSL {
+		return LIBC_MUSL
+	}
+	return ""
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+)
+
+type securitySettings struct {
+	Hardened        bool   `json:"hardened"`
+	VerifySignature *bool  `json:"verify_signature"`
+	Keyring         string `json:"keyring"`
+
+	CACert     string `json:"ca_cert"`
+	ClientCert string `json:"client_cert"`
+	ClientKey  string `json:"client_key"`
+	Proxy      string `json:"proxy"`
+}
+
+// loadSecurity applies the hardened profile and signature settings. A
+// hardened config verifies release signatures unless verify_signature
+// says otherwise.
+func (c *Config) loadSecurity(settings securitySettings) error {
+	c.Hardened = settings.Hardened
+	if hardened := os.Getenv("GNODE_HARDENED"); hardened != "" {
+		c.Hardened = isTruthy(hardened)
+	}
+
+	c.VerifySignature = c.Hardened
+	if settings.VerifySignature != nil {
+		c.VerifySignature = *settings.VerifySignature
+	}
+	if verify := os.Getenv("GNODE_VERIFY_SIGNATURE"); verify != "" {
+		c.VerifySignature = isTruthy(verify)
+	}
+
+	c.Keyring = settings.Keyring
+	if keyring := os.Getenv("GNODE_KEYRING"); keyring != "" {
+		c.Keyring = keyring
+	}
+
+	return c.loadTLS(settings)
+}
+
+// loadTLS reads the CA bundle, client certificate and proxy settings used
+// for every request. Insecure is only taken from GNODE_INSECURE or the
+// --insecure flag, never from config.json, so it cannot be left on by
+// accident.
+func (c *Config) loadTLS(settings securitySettings) error {
+	c.CACert = envOverride("GNODE_CA_CERT", settings.CACert)
+	c.ClientCert = envOverride("GNODE_CLIENT_CERT", settings.ClientCert)
+	c.ClientKey = envOverride("GNODE_CLIENT_KEY", settings.ClientKey)
+	if (c.ClientCert == "") != (c.ClientKey == "") {
+		return fmt.Errorf("client_cert and client_key must be set together")
+	}
+
+	c.Proxy = envOverride("GNODE_PROXY", settings.Proxy)
+	if c.Proxy != "" {
+		proxy, err := url.Parse(c.Proxy)
+		if err != nil || proxy.Host == "" {
+			return fmt.Errorf("invalid proxy URL %q", c.Proxy)
+		}
+		switch proxy.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return fmt.Errorf("invalid proxy URL %q: expected an http, https or socks5 URL", c.Proxy)
+		}
+		if username := os.Getenv("GNODE_PROXY_USERNAME"); username != "" {
+			proxy.User = url.UserPassword(username, os.Getenv("GNODE_PROXY_PASSWORD"))
+		}
+		c.Proxy = proxy.String()
+	}
+
+	if insecure := os.Getenv("GNODE_INSECURE"); insecure != "" {
+		c.Insecure = isTruthy(insecure)
+	}
+	return nil
+}
+
+// envOverride returns the environment variable name when set, else the
+// value from config.json.
+func envOverride(name, value string) string {
+	if env := os.Getenv(name); env != "" {
+		return env
+	}
+	return value
+}
@@ -0,0 +1,186 @@
+// Package progress defines the events gnode reports while downloading and
+// extracting, and the Tracker that produces them.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+type Stage string
+
+const (
+	StageDownload Stage = "download"
+	StageExtract  Stage = "extract"
+)
+
+// Event describes how far a download or an extraction has got. Bytes and
+// Total count the archive or file being transferred; Total is 0 when the
+// size is unknown, and so is ETA.
+type Event struct {
+	Stage Stage
+	Name  string
+	Bytes int64
+	Total int64
+	Rate  float64 // bytes per second
+	ETA   time.Duration
+	Files int // entries written so far, when extracting
+	Done  bool
+	Err   error // why the operation stopped, with Done
+}
+
+// Percent returns how much of Total is done, or -1 when Total is unknown.
+func (e Event) Percent() float64 {
+	if e.Total <= 0 {
+		return -1
+	}
+	return float64(e.Bytes) * 100 / float64(e.Total)
+}
+
+// Reporter receives progress events. Events of one operation arrive in
+// order and end with one that has Done set.
+type Reporter interface {
+	Progress(Event)
+}
+
+// ReporterFunc adapts a function to Reporter.
+type ReporterFunc func(Event)
+
+func (f ReporterFunc) Progress(e Event) {
+	f(e)
+}
+
+// Interval is the minimum time between two events of an operation, not
+// counting the final one.
+const Interval = 100 * time.Millisecond
+
+// Tracker turns byte and file counts into throttled events. A Tracker with
+// a nil Reporter does nothing, so callers need not check.
+type Tracker struct {
+	mu       sync.Mutex
+	reporter Reporter
+	event    Event
+	start    time.Time
+	offset   int64
+	last     time.Time
+	done     bool
+}
+
+// NewTracker starts tracking an operation whose first offset bytes were
+// already done earlier, such as a resumed download. They count towards
+// Bytes but not towards Rate.
+func NewTracker(r Reporter, stage Stage, name string, offset, total int64) *Tracker {
+	t := &Tracker{
+		reporter: r,
+		event:    Event{Stage: stage, Name: name, Bytes: offset, Total: total},
+		start:    time.Now(),
+		offset:   offset,
+	}
+	t.emit(true)
+	return t
+}
+
+// Add records n more bytes.
+func (t *Tracker) Add(n int64) {
+	if t.reporter == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.event.Bytes += n
+	t.emit(false)
+}
+
+// AddFile records one more extracted entry.
+func (t *Tracker) AddFile() {
+	if t.reporter == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.event.Files++
+	t.emit(false)
+}
+
+// Done sends the final event. Later calls are ignored.
+func (t *Tracker) Done(err error) {
+	if t.reporter == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.done {
+		return
+	}
+	t.done = true
+	t.event.Done = true
+	t.event.Err = err
+	t.emit(true)
+}
+
+// Reader counts the bytes read through r.
+func (t *Tracker) Reader(r io.Reader) io.Reader {
+	if t.reporter == nil {
+		return r
+	}
+	return &countingReader{r: r, t: t}
+}
+
+// emit sends the current state unless the last event was sent less than
+// Interval ago. The caller holds t.mu.
+func (t *Tracker) emit(force bool) {
+	if t.reporter == nil {
+		return
+	}
+	now := time.Now()
+	if !force && now.Sub(t.last) < Interval {
+		return
+	}
+	t.last = now
+
+	if elapsed := now.Sub(t.start).Seconds(); elapsed > 0 {
+		t.event.Rate = float64(t.event.Bytes-t.offset) / elapsed
+	}
+	t.event.ETA = 0
+	if t.event.Total > 0 && t.event.Rate > 0 && t.event.Bytes < t.event.Total {
+		t.event.ETA = time.Duration(float64(t.event.Total-t.event.Bytes) / t.event.Rate * float64(time.Second))
+	}
+	t.reporter.Progress(t.event)
+}
+
+type countingReader struct {
+	r io.Reader
+	t *Tracker
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.t.Add(int64(n))
+	return n, err
+}
+
+// FormatBytes renders a size with a binary unit, e.g. "12.3 MB".
+func FormatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// FormatRate renders a transfer rate, e.g. "4.2 MB/s".
+func FormatRate(bytesPerSecond float64) string {
+	switch {
+	case bytesPerSecond >= 1<<20:
+		return fmt.Sprintf("%.1f MB/s", bytesPerSecond/(1<<20))
+	case bytesPerSecond >= 1<<10:
+		return fmt.Sprintf("%.1f KB/s", bytesPerSecond/(1<<10))
+	}
+	return fmt.Sprintf("%.0f B/s", bytesPerSecond)
+}